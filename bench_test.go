@@ -0,0 +1,64 @@
+package structopt
+
+import (
+	"testing"
+)
+
+type BenchSub struct {
+	Help
+
+	Force bool   `short:"f"`
+	Level string `choice:"debug info warn error" default:"info"`
+}
+
+type Bench struct {
+	Help
+
+	Verbose bool     `short:"v" help:"show the verbose message"`
+	Name    string   `short:"n" default:"bench"`
+	Size    uint64   `unit:"bytes" default:"64K"`
+	Tags    []string `choice:"c b a" default:"a b"`
+	Targets IPSet    `limit:"1024"`
+	Sub     *BenchSub
+	Output  *string
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for idx := 0; idx < b.N; idx++ {
+		if _, err := New(&Bench{}); err != nil {
+			b.Fatalf("cannot create the parser: %v", err)
+		}
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	args := []string{"-v", "-n", "foo", "--size", "1MiB", "--tags", "c", "--targets", "10.0.0.1-50", "output", "benchsub", "-f"}
+
+	parser := MustNew(&Bench{})
+	defer parser.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for idx := 0; idx < b.N; idx++ {
+		if _, err := parser.Set(args...); err != nil {
+			b.Fatalf("cannot set %v: %v", args, err)
+		}
+
+		// parse the same arguments again from the initial state
+		if err := parser.Reset(); err != nil {
+			b.Fatalf("cannot reset: %v", err)
+		}
+	}
+}
+
+func BenchmarkNewParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := New(&Bench{}); err != nil {
+				b.Errorf("cannot create the parser: %v", err)
+			}
+		}
+	})
+}
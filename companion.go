@@ -0,0 +1,98 @@
+package structopt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// analyze the companion option <name>-file which read the value from the file
+func analyze_companion(source *field_schema) (companion *flag_schema, err error) {
+	if source.kind != Flag && source.kind != Argument {
+		err = fmt.Errorf("file companion only used in flag or argument: %v (%v)", source.name, source.kind)
+		return
+	}
+
+	target := "--" + source.name
+	if source.kind == Argument {
+		target = strings.ToUpper(source.name)
+	}
+
+	companion = &flag_schema{
+		StructTag: reflect.StructTag(fmt.Sprintf("%v:%v", TAG_HELP, strconv.Quote("read "+target+" from the file"))),
+
+		name: source.name + "-file",
+
+		option_type:      Flag,
+		option_type_hint: PATH,
+	}
+	return
+}
+
+// bind the companion option <name>-file which read the value from the file
+func (opt *StructOpt) new_file_companion(source *FlipFlag, schema *flag_schema) (err error) {
+	companion := &FlipFlag{
+		Value:       source.Value,
+		flag_schema: schema,
+
+		setting: source.setting,
+		source:  source,
+	}
+	source.companion = companion
+
+	name := companion.Name()
+	if old, ok := opt.named_options[name]; ok {
+		log.Warn("duplicated field: %v (%v)", name, old)
+		err = fmt.Errorf("duplicated field: %v", name)
+		return
+	}
+
+	opt.named_options[name] = companion
+	opt.ff_options = append(opt.ff_options, companion)
+	log.Info("add new named option: --%v", name)
+	return
+}
+
+// read the value of the source option from the file, and trim the spaces
+func (option *FlipFlag) set_companion(args ...string) (count int, err error) {
+	var data []byte
+
+	source := option.source
+	switch {
+	case len(args) == 0:
+		err = fmt.Errorf("%v should pass %v", option.Name(), option.TypeHint())
+		return
+	case source.assigned:
+		err = fmt.Errorf("%v cannot be used with --%v", option.Name(), source.Name())
+		return
+	}
+
+	if data, err = ioutil.ReadFile(args[0]); err != nil {
+		err = fmt.Errorf("cannot read %#v: %v", args[0], err)
+		return
+	}
+
+	value := strings.TrimSpace(string(data))
+	if err = source.set_arg(value); err != nil {
+		err = fmt.Errorf("%v from %v: %v", source.Name(), args[0], err)
+		return
+	}
+
+	option.assigned = true
+	count++
+
+	if source.Callback != nil {
+		// call the callback of the source option
+		log.Trace("execute callback %v", source.Callback)
+		source.Callback(source)
+
+		if source.exited {
+			// stopped by the callback, like the help in the REPL
+			err = fmt.Errorf("exit by %v", source.Name())
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package structopt
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type Database struct {
+	Password string  `option:"secret,file"`
+	Port     int     `option:"file" default:"5432"`
+	Dsn      *string `option:"file"`
+}
+
+func TestFileCompanion(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"password": "db-password\n",
+		"port":     "  6543 \n",
+		"dsn":      "postgres://db\n",
+		"invalid":  "x5432y\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("cannot create %v: %v", name, err)
+		}
+	}
+
+	db := Database{}
+	parser := MustNew(&db)
+	stderr := &strings.Builder{}
+	parser.SetStderr(stderr)
+	parser.SetWidth(USAGE_WIDTH)
+
+	if usage := parser.Usage(); !strings.Contains(usage, "--password-file") || !strings.Contains(usage, "read DSN from the file") {
+		t.Errorf("expect show the companion options: %v", usage)
+	}
+
+	args := []string{"--password-file", filepath.Join(dir, "password"), "--port-file", filepath.Join(dir, "port"), "--dsn-file", filepath.Join(dir, "dsn")}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case db.Password != "db-password":
+		t.Errorf("expect --password from the file: %v", db.Password)
+	case db.Port != 6543:
+		t.Errorf("expect --port from the file: %v", db.Port)
+	case db.Dsn == nil || *db.Dsn != "postgres://db":
+		t.Errorf("expect DSN from the file: %v", db.Dsn)
+	case stderr.Len() != 0:
+		t.Errorf("expect no warning of the secret from the file: %v", stderr)
+	}
+
+	cases := [][]string{
+		{"--password", "x", "--password-file", filepath.Join(dir, "password")},
+		{"--port-file", filepath.Join(dir, "port"), "--port", "1"},
+		{"--port-file", filepath.Join(dir, "invalid")},
+		{"--port-file", filepath.Join(dir, "not-exists")},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
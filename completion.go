@@ -0,0 +1,90 @@
+package structopt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the option which can complete its value
+type completer interface {
+	complete(prefix string) []string
+}
+
+// Complete the last argument by the options, sub-commands or the value of the option.
+func (opt *StructOpt) Complete(args ...string) (candidates []string) {
+	prefix := ""
+	if len(args) > 0 {
+		prefix = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	current := opt
+	arg_idx := 0
+	var pending Option
+
+	disable_option := false
+	for _, arg := range args {
+		switch {
+		case pending != nil:
+			// the value of the option
+			pending = nil
+		case !disable_option && arg == "--":
+			disable_option = true
+		case !disable_option && len(arg) > 1 && arg[0] == '-':
+			name := strings.TrimLeft(arg, "-")
+			if option, ok := current.named_options[name]; ok && option.Type() == Flag {
+				// the next argument is the value
+				pending = option
+			}
+		case arg_idx < len(current.arg_options):
+			if !is_multiple(current.arg_options[arg_idx]) {
+				// the repeatable argument consume all the remains arguments
+				arg_idx++
+			}
+		default:
+			if sub, ok := current.named_options[arg].(*StructOpt); ok && sub.Type() == Subcommand {
+				// enter the sub-command
+				current = sub
+				arg_idx = 0
+				disable_option = false
+			}
+		}
+	}
+
+	switch {
+	case pending != nil:
+		candidates = complete_option(pending, prefix)
+	case !disable_option && strings.HasPrefix(prefix, "-"):
+		for _, option := range current.ff_options {
+			if name := fmt.Sprintf("--%v", option.Name()); strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, name)
+			}
+			if short := option.ShortName(); short != "" && strings.HasPrefix("-"+short, prefix) {
+				candidates = append(candidates, "-"+short)
+			}
+		}
+		sort.Strings(candidates)
+	default:
+		if arg_idx < len(current.arg_options) {
+			// the argument
+			candidates = complete_option(current.arg_options[arg_idx], prefix)
+		}
+
+		for _, sub := range current.sub_options {
+			if strings.HasPrefix(sub.Name(), prefix) {
+				// the sub-command
+				candidates = append(candidates, sub.Name())
+			}
+		}
+	}
+	return
+}
+
+// complete the value of the option, if supported
+func complete_option(option Option, prefix string) (candidates []string) {
+	if c, ok := option.(completer); ok {
+		candidates = c.complete(prefix)
+	}
+	return
+}
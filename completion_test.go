@@ -0,0 +1,104 @@
+package structopt
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type Deploy struct {
+	Help
+
+	Output string `short:"o" path:"dir exists env" help:"the output directory"`
+	Socket string `path:"creatable abs" help:"the unix socket"`
+	Root   fs.FS  `help:"the root directory"`
+	Level  string `choice:"warn info debug"`
+
+	Config *string `path:"file exists" help:"the config file"`
+
+	*Sub `help:"the sub-command"`
+}
+
+func TestPathOption(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(config, []byte("key: value"), 0644); err != nil {
+		t.Fatalf("cannot create %v: %v", config, err)
+	}
+
+	t.Setenv("STRUCTOPT_TEST_DIR", dir)
+
+	deploy := Deploy{}
+	parser := MustNew(&deploy)
+
+	args := []string{"-o", "$STRUCTOPT_TEST_DIR", "--socket", filepath.Join(dir, "app$STRUCTOPT_TEST_DIR.sock"), "--root", dir, config}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set the path: %v", err)
+	}
+
+	switch {
+	case deploy.Output != dir:
+		t.Errorf("expect --output expanded %v: %v", dir, deploy.Output)
+	case deploy.Socket != filepath.Join(dir, "app$STRUCTOPT_TEST_DIR.sock"):
+		t.Errorf("expect --socket keep the $ as written: %v", deploy.Socket)
+	case deploy.Config == nil || *deploy.Config != config:
+		t.Errorf("expect CONFIG %v: %v", config, deploy.Config)
+	}
+
+	if data, err := fs.ReadFile(deploy.Root, "config.yaml"); err != nil || string(data) != "key: value" {
+		t.Errorf("expect --root as fs.FS: %#v (%v)", string(data), err)
+	}
+
+	cases := [][]string{
+		{"-o", config},
+		{"-o", filepath.Join(dir, "not-exist")},
+		{"-o", "$STRUCTOPT_TEST_UNSET"},
+		{"--socket", filepath.Join(dir, "not-exist", "app.sock")},
+		{"--root", config},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha", "beta"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatalf("cannot create %v: %v", name, err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "alpha.txt"), nil, 0644); err != nil {
+		t.Fatalf("cannot create alpha.txt: %v", err)
+	}
+
+	deploy := Deploy{}
+	parser := MustNew(&deploy)
+
+	prefix := dir + string(filepath.Separator)
+	cases := []struct {
+		args       []string
+		candidates []string
+	}{
+		{[]string{"--l"}, []string{"--level"}},
+		{[]string{"-"}, []string{"--help", "--level", "--output", "--root", "--socket", "-h", "-o"}},
+		{[]string{"--level", "d"}, []string{"debug"}},
+		{[]string{"-o", prefix + "a"}, []string{prefix + "alpha/"}},
+		{[]string{prefix + "al"}, []string{prefix + "alpha.txt", prefix + "alpha/"}},
+		{[]string{"config.yaml", "s"}, []string{"sub"}},
+		{[]string{"config.yaml", "sub", "--"}, []string{"--help", "--rat"}},
+	}
+
+	for _, c := range cases {
+		candidates := parser.Complete(c.args...)
+		if !reflect.DeepEqual(candidates, c.candidates) {
+			t.Errorf("expect complete %v: %v: %v", c.args, c.candidates, candidates)
+		}
+	}
+}
@@ -26,27 +26,163 @@ const (
 	TAG_CALLBACK = "callback"
 	TAG_CHOICE   = "choice"
 	TAG_DEFAULT  = "default"
+	TAG_UNIT     = "unit"
+	TAG_LAYOUT   = "layout"
+	TAG_TZ       = "tz"
+	TAG_MODE     = "mode"
+	TAG_PERM     = "perm"
+	TAG_PATH     = "path"
+	TAG_GLOB     = "glob"
+	TAG_RESOLVE  = "resolve"
+	TAG_FAMILY   = "family"
+	TAG_CIDR     = "cidr"
+	TAG_SCHEME   = "scheme"
+	TAG_LIMIT    = "limit"
+	TAG_ENCODING = "encoding"
+	TAG_LEN      = "len"
+	TAG_CONFIRM  = "confirm"
 
 	// special tag which no-need provide the valie
 	TAG_OPTION     = "option"
 	TAG_OPTION_SEP = ","
+	// the separator of the multiple time layouts
+	TAG_LAYOUT_SEP = "|"
 	// used to node the field allow data truncated
 	TAG_SKIP     = "skip"
 	TAG_FLAG     = "flag"
+	TAG_JSON     = "json"
+	TAG_SECRET   = "secret"
+	TAG_FILE     = "file"
 	TAG_TRUNC    = "trunc"
 	TAG_REQUIRED = "required"
 )
 
+// pre-define the unit of the INT/UINT, used in TAG_UNIT
+const (
+	// the size with binary (K, Ki, KiB) or decimal (KB) suffix
+	UNIT_BYTES = "bytes"
+	// the SI prefix (k, M, G) or binary (Ki, Mi) suffix
+	UNIT_SI = "si"
+)
+
+// pre-define the mode of the FILE, used in TAG_MODE
+const (
+	FILE_READ      = "read"
+	FILE_WRITE     = "write"
+	FILE_APPEND    = "append"
+	FILE_CREATE    = "create"
+	FILE_TRUNCATE  = "truncate"
+	FILE_EXCLUSIVE = "exclusive"
+)
+
+// pre-define the rule of the PATH, used in TAG_PATH
+const (
+	// the path should exist
+	PATH_EXISTS = "exists"
+	// the path should be the directory, if exists
+	PATH_DIR = "dir"
+	// the path should be the regular file, if exists
+	PATH_FILE = "file"
+	// the path can be created, the parent directory should exist
+	PATH_CREATABLE = "creatable"
+	// convert to the absolute path
+	PATH_ABS = "abs"
+	// expand the environment variable, like $HOME
+	PATH_ENV = "env"
+)
+
+// pre-define the mode of the glob pattern, used in TAG_GLOB
+const (
+	// pass the pattern literally when no matches, by default
+	GLOB_LITERAL = "literal"
+	// raise error when no matches
+	GLOB_STRICT = "strict"
+)
+
+// pre-define the address family, used in TAG_RESOLVE and TAG_FAMILY
+const (
+	// any address, by default
+	RESOLVE_IP = "ip"
+	// only the IPv4 address
+	RESOLVE_IP4 = "ip4"
+	// only the IPv6 address
+	RESOLVE_IP6 = "ip6"
+)
+
+// pre-define the mode of the CIDR, used in TAG_CIDR
+const (
+	// keep the host address with the mask, like 10.0.0.5/24
+	CIDR_HOST = "host"
+	// the address should be the canonical network address, like 10.0.0.0/24
+	CIDR_NETWORK = "network"
+)
+
+// pre-define the source of the secret option, and the mask used to redact the secret
+const (
+	// read from the environment variable, like env:DB_PASSWORD
+	SECRET_ENV = "env:"
+	// read from the file, like file:/run/secrets/db
+	SECRET_FILE = "file:"
+	// ask from the stdin without echo
+	SECRET_PROMPT = "prompt"
+
+	SECRET_MASK = "******"
+)
+
+// pre-define the layout of the usage
+const (
+	// the width when the terminal width cannot be detected
+	USAGE_WIDTH = 80
+	// the minimal width of the help column, the row overflows when the terminal is too narrow
+	USAGE_HELP_WIDTH = 20
+	// the leading spaces of the row, and the spaces between the flag and help column
+	USAGE_MARGIN = 4
+	USAGE_GAP    = 2
+)
+
+// the maximal attempts to ask the missing required option, see StructOpt.SetPrompt
+const PROMPT_RETRY = 3
+
+// pre-define the option which bypass the confirmation, generated by TAG_CONFIRM
+const (
+	CONFIRM_YES       = "yes"
+	CONFIRM_YES_SHORT = "y"
+)
+
+// pre-define the encoding of the BYTES, used in TAG_ENCODING
+const (
+	ENCODING_RAW       = "raw"
+	ENCODING_HEX       = "hex"
+	ENCODING_BASE64    = "base64"
+	ENCODING_BASE64URL = "base64url"
+)
+
 // pre-define the INT/UINT format
 var (
+	RE_UNDERSCORE = regexp.MustCompile(`^(?:0[bBoOxX]_?)?[0-9a-fA-F]+(?:_[0-9a-fA-F]+)*$`)
+	RE_UNIT       = regexp.MustCompile(`^([0-9][0-9_]*(?:\.[0-9][0-9_]*)?)([a-zA-Z]+)$`)
+
 	RE_INT = regexp.MustCompile(`^0|[1-9][0-9]*$`)
 	RE_BIN = regexp.MustCompile(`^(:?0[bB])([01]+)$`)
 	RE_OCT = regexp.MustCompile(`^(:?0[oO]?)([0-7]+)$`)
 	RE_HEX = regexp.MustCompile(`^(:?0[xX])([0-9a-fA-F]+)$`)
 
-	RE_FLOAT = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)?\.[0-9]+$`)
+	RE_FLOAT = regexp.MustCompile(`^[+-]?(?:(?:[0-9]+\.[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?|[0-9]+[eE][+-]?[0-9]+)$`)
 	RE_RAT   = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)/-?[1-9][0-9]*$`)
+
+	RE_ZONE_OFFSET = regexp.MustCompile(`^(?:UTC|GMT)?([+-])([0-9]{1,2})(?::?([0-9]{2}))?$`)
+
+	RE_SPAN     = regexp.MustCompile(`([0-9]*(?:\.[0-9]*)?)([a-zA-Zµμ]+)`)
+	RE_ISO_SPAN = regexp.MustCompile(`^P(?:([0-9.]+)W)?(?:([0-9.]+)D)?(?:T(?:([0-9.]+)H)?(?:([0-9.]+)M)?(?:([0-9.]+)S)?)?$`)
 )
 
+// the log sub-system, implemented by the logger.Log
+type logging interface {
+	Trace(format string, args ...interface{})
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+}
+
 // The inner log sub-system, used for trace and warning log.
-var log = logger.New(PROJ_NAME)
+var log logging = logger.New(PROJ_NAME)
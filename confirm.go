@@ -0,0 +1,112 @@
+package structopt
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the generated --yes option, shared by the parsers
+var yes_schema = &flag_schema{
+	StructTag: reflect.StructTag(fmt.Sprintf("%v:%v %v:%v", TAG_SHORT, strconv.Quote(CONFIRM_YES_SHORT),
+		TAG_HELP, strconv.Quote("assume yes to the confirmation"))),
+
+	name: CONFIRM_YES,
+
+	option_type:      Flip,
+	option_type_hint: NONE,
+}
+
+// generate the --yes option which bypass the confirmation, shared by the sub-commands
+func (opt *StructOpt) new_yes_option() (err error) {
+	if opt.yes_option != nil {
+		// generated before
+		return
+	}
+
+	value := reflect.ValueOf(&opt.setting.yes).Elem()
+	opt.yes_option = &FlipFlag{
+		Value:       value,
+		flag_schema: yes_schema,
+
+		setting: opt.setting,
+		initial: clone_value(value),
+	}
+
+	for _, name := range []string{opt.yes_option.Name(), opt.yes_option.ShortName()} {
+		if old, ok := opt.named_options[name]; ok {
+			log.Warn("duplicated field: %v (%v)", name, old)
+			err = fmt.Errorf("duplicated field: %v", name)
+			return
+		}
+		opt.named_options[name] = opt.yes_option
+	}
+
+	opt.ff_options = append(opt.ff_options, opt.yes_option)
+	log.Info("add new named option: --%v", CONFIRM_YES)
+	return
+}
+
+// confirm the sub-command and the options set in the current parsing by TAG_CONFIRM
+func (opt *StructOpt) check_confirm() (err error) {
+	if opt.confirm != "" {
+		if err = opt.ask_confirm(opt.Name(), opt.confirm); err != nil {
+			return
+		}
+	}
+
+	for _, options := range [][]Option{opt.ff_options, opt.arg_options} {
+		for _, option := range options {
+			flip, ok := option.(*FlipFlag)
+			if !ok || flip.Get(TAG_CONFIRM) == "" {
+				// no-need to confirm
+				continue
+			}
+
+			if !flip.assigned && (flip.companion == nil || !flip.companion.assigned) {
+				// not set in the current parsing, like the default
+				continue
+			}
+
+			name := "--" + flip.Name()
+			if flip.Type() == Argument {
+				name = strings.ToUpper(flip.Name())
+			}
+
+			if err = opt.ask_confirm(name, flip.Get(TAG_CONFIRM)); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// ask the yes/no question, fail if not confirmed or cannot ask in the non-interactive mode
+func (opt *StructOpt) ask_confirm(name, message string) (err error) {
+	var answer string
+
+	switch {
+	case opt.setting.yes:
+		log.Info("bypass the confirmation of %v", name)
+		return
+	case !opt.interactive():
+		err = fmt.Errorf("%v should be confirmed, pass --%v in the non-interactive mode", name, CONFIRM_YES)
+		return
+	}
+
+	fmt.Fprintf(opt.stderr, "%v [y/N]: ", message)
+	if answer, err = read_line(opt.stdin); err != nil {
+		fmt.Fprintln(opt.stderr)
+		err = fmt.Errorf("%v is not confirmed: %v", name, err)
+		return
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		log.Info("%v is confirmed", name)
+	default:
+		err = fmt.Errorf("%v is not confirmed", name)
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package structopt
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+type WarehouseDrop struct {
+	Force bool `short:"f"`
+}
+
+type Warehouse struct {
+	Purge bool           `confirm:"purge the cached data?"`
+	Name  string         `default:"main"`
+	Drop  *WarehouseDrop `name:"drop" confirm:"drop the warehouse?"`
+}
+
+func TestConfirmOption(t *testing.T) {
+	cases := []struct {
+		Args   []string
+		Answer string
+		Prompt string
+		Error  string
+	}{
+		{Args: []string{"--name", "archive"}},
+		{Args: []string{"--purge"}, Answer: "yes\n", Prompt: "purge the cached data? [y/N]: "},
+		{Args: []string{"--purge"}, Answer: "n\n", Prompt: "purge the cached data? [y/N]: ", Error: "--purge is not confirmed"},
+		{Args: []string{"--purge"}, Prompt: "purge the cached data? [y/N]: \n", Error: "--purge is not confirmed: EOF"},
+		{Args: []string{"-y", "--purge"}},
+		{Args: []string{"drop", "-f"}, Answer: "Y\n", Prompt: "drop the warehouse? [y/N]: "},
+		{Args: []string{"drop", "--yes"}},
+		{Args: []string{"--yes", "--purge", "drop"}},
+	}
+
+	for _, c := range cases {
+		parser := MustNew(&Warehouse{})
+		stderr := &strings.Builder{}
+		parser.SetStderr(stderr)
+		parser.SetStdin(strings.NewReader(c.Answer))
+
+		_, err := parser.Set(c.Args...)
+		switch {
+		case c.Error == "" && err != nil:
+			t.Errorf("cannot set %v: %v", c.Args, err)
+		case c.Error != "" && (err == nil || err.Error() != c.Error):
+			t.Errorf("expect %v fail %#v: %v", c.Args, c.Error, err)
+		case stderr.String() != c.Prompt:
+			t.Errorf("expect %v ask %#v: %#v", c.Args, c.Prompt, stderr.String())
+		}
+	}
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("cannot create the pipe: %v", err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	parser := MustNew(&Warehouse{})
+	parser.SetStdin(reader)
+	if _, err := parser.Set("drop"); err == nil || !strings.Contains(err.Error(), "pass --yes in the non-interactive mode") {
+		t.Errorf("expect fail in the non-interactive mode: %v", err)
+	}
+}
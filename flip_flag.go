@@ -1,12 +1,19 @@
 package structopt
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -15,30 +22,31 @@ type FlipFlag struct {
 	// The raw value of the input struct, should be the pointer of the value.
 	reflect.Value
 
-	// The field of the option in the struct
-	reflect.StructTag
+	// The static properties of the option, shared by the parsers of the same struct
+	*flag_schema
 
 	// The callback function, may nil
 	Callback
 
-	// Name of the command-line, default is the name of struct.
-	name string
-	// The pre-defined value can used.
-	choices []string
 	// The default value
 	default_value string
-	// option is required
-	required         bool
-	option_type      Type
-	option_type_hint TypeHint
+	// The --x-file companion option, and the source option of the companion
+	companion *FlipFlag
+	source    *FlipFlag
+	// The option is set in the current parsing, used in the mutual exclusion
+	assigned bool
+	// The initial state, restored by Reset
+	initial           reflect.Value
+	initial_defaulted bool
+	// The value is the default, and should be replaced when set, used in slice and IPSET
+	defaulted bool
+
+	// The runtime setting shared with the StructOpt
+	*setting
 }
 
 func (option *FlipFlag) Name() (name string) {
 	name = option.name
-	if n := option.StructTag.Get(TAG_NAME); n != "" {
-		// override the option name
-		name = n
-	}
 	return
 }
 
@@ -47,12 +55,15 @@ func (option *FlipFlag) ShortName() (name string) {
 	return
 }
 
+// Show as the single row of the usage, see StructOpt.Usage for the aligned and wrapped rows.
 func (option *FlipFlag) String() (str string) {
-	// show as the formatted option which has three parts: margin, option and help
-	help, _ := option.Lookup(TAG_HELP)
-	flag := ""
-	flag_width := 24
+	str = new_usage_layout([]Option{option}, 0).row(option)
+	return
+}
 
+// the columns of the usage row: the short name, the long name and the words of the help, the
+// annotation like (default: x) is kept as the single word which is never wrapped
+func (option *FlipFlag) columns() (short, long string, help []string) {
 	type_hint := option.TypeHint().String()
 	if option.TypeHint() == NONE {
 		type_hint = ""
@@ -60,48 +71,94 @@ func (option *FlipFlag) String() (str string) {
 
 	switch option.Type() {
 	case Flip, Flag:
-		short_name, _ := option.Lookup(TAG_SHORT)
-		if len(short_name) > 0 {
+		if short = option.ShortName(); short != "" {
 			// add the leading -
-			short_name = fmt.Sprintf("-%-v %v", short_name, type_hint)
-			short_name = strings.TrimSpace(short_name)
+			short = strings.TrimSpace(fmt.Sprintf("-%v %v", short, type_hint))
 		}
-		short_width_offset := WidecharSize(short_name) - len([]rune(short_name))
-		flag = fmt.Sprintf("%*v --%v %v", 8-short_width_offset, short_name, option.Name(), type_hint)
+		long = strings.TrimSpace(fmt.Sprintf("--%v %v", option.Name(), type_hint))
 	default:
-		flag = fmt.Sprintf("%v [%v]", strings.ToUpper(option.Name()), option.TypeHint())
-		flag_width = 12
+		long = fmt.Sprintf("%v [%v]", strings.ToUpper(option.Name()), option.TypeHint())
 	}
 
-	flag_width_offset := WidecharSize(flag) - len([]rune(flag))
-	str = fmt.Sprintf("    %-*v %v", flag_width-flag_width_offset, flag, help)
-
+	help = strings.Fields(option.Get(TAG_HELP))
 	if len(option.choices) > 0 {
 		// show the choices
-		str = fmt.Sprintf("%v %v", str, option.choices)
+		help = append(help, strings.Fields(fmt.Sprintf("%v", option.choices))...)
 	}
 
-	if option.default_value != "" {
-		// has default value
-		str = fmt.Sprintf("%v (default: %v)", str, option.default_value)
+	if option.unit != "" {
+		// show the unit
+		help = append(help, fmt.Sprintf("(unit: %v)", option.unit))
+	}
+
+	if mode := option.StructTag.Get(TAG_MODE); mode != "" {
+		// show the file mode
+		help = append(help, fmt.Sprintf("(mode: %v)", mode))
+	}
+
+	if schemes := option.StructTag.Get(TAG_SCHEME); schemes != "" {
+		// show the allowed schemes
+		help = append(help, fmt.Sprintf("(scheme: %v)", schemes))
+	}
+
+	if encoding := option.StructTag.Get(TAG_ENCODING); encoding != "" {
+		// show the encoding
+		help = append(help, fmt.Sprintf("(encoding: %v)", encoding))
 	}
 
-	str = strings.TrimRight(str, " ")
+	if length := option.StructTag.Get(TAG_LEN); length != "" {
+		// show the length range
+		help = append(help, fmt.Sprintf("(len: %v)", length))
+	}
+
+	if option.limit > 0 {
+		// show the size limit
+		help = append(help, fmt.Sprintf("(limit: %v)", option.limit))
+	}
+
+	if rules := option.StructTag.Get(TAG_PATH); rules != "" {
+		// show the path rules
+		help = append(help, fmt.Sprintf("(path: %v)", rules))
+	}
+
+	if len(option.layouts) > 0 {
+		// show the accepted time layouts
+		help = append(help, fmt.Sprintf("(layout: %v)", strings.Join(LayoutFormats(option.layouts), TAG_LAYOUT_SEP)))
+	}
+
+	switch {
+	case option.default_value != "" && option.secret:
+		// never show the secret
+		help = append(help, fmt.Sprintf("(default: %v)", SECRET_MASK))
+	case option.default_value != "":
+		// has default value
+		help = append(help, fmt.Sprintf("(default: %v)", option.default_value))
+	}
 	return
 }
 
 func (option *FlipFlag) Set(args ...string) (count int, err error) {
-	value := option.Value
-	for value.Kind() == reflect.Ptr {
-		if value.IsZero() {
-			// create dummy instance
-			value.Set(reflect.New(value.Type().Elem()))
-		}
-		value = value.Elem()
+	switch {
+	case option.source != nil:
+		// the --x-file companion option
+		count, err = option.set_companion(args...)
+		return
+	case option.companion != nil && option.companion.assigned:
+		err = fmt.Errorf("%v cannot be used with --%v", option.Name(), option.companion.Name())
+		return
 	}
 
 	switch option.Type() {
 	case Flip:
+		value := option.Value
+		for value.Kind() == reflect.Ptr {
+			if value.IsZero() {
+				// create dummy instance
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+
 		// flip the value
 		value.SetBool(!value.Bool())
 	case Flag, Argument:
@@ -109,37 +166,242 @@ func (option *FlipFlag) Set(args ...string) (count int, err error) {
 			err = fmt.Errorf("%v should pass %v", option.Name(), option.TypeHint())
 			return
 		}
-		arg := args[0]
 
-		if len(option.choices) > 0 {
-			idx := sort.SearchStrings(option.choices, arg)
-			if idx == len(option.choices) || option.choices[idx] != arg {
-				err = fmt.Errorf("set %v: %v not in %v", option.Name(), arg, option.choices)
+		arg := args[0]
+		if option.secret {
+			if arg, err = option.read_secret(arg); err != nil {
+				// cannot read the secret
 				return
 			}
 		}
 
-		switch option.TypeHint() {
-		case INT:
-			var val int64
+		if err = option.set_arg(arg); err != nil {
+			return
+		}
+		count++
+	default:
+		err = fmt.Errorf("should not be here: %v", option.Type())
+		return
+	}
 
-			if val, err = AtoI(arg); err != nil {
+	option.assigned = true
+
+	if option.Callback != nil {
+		// call the callback
+		log.Trace("execute callback %v", option.Callback)
+		option.Callback(option)
+
+		if option.exited {
+			// stopped by the callback, like the help in the REPL
+			err = fmt.Errorf("exit by %v", option.Name())
+			return
+		}
+	}
+	return
+}
+
+// restore the initial state, the companion shares the value with the source option
+func (option *FlipFlag) reset() {
+	if option.source == nil {
+		option.Value.Set(clone_value(option.initial))
+		option.defaulted = option.initial_defaulted
+	}
+	option.assigned = false
+}
+
+// set the value of the flag or argument, the error of the secret never contains the value
+func (option *FlipFlag) set_arg(arg string) (err error) {
+	switch {
+	case option.multiple:
+		err = option.append_value(arg)
+	default:
+		err = option.set_single_value(arg)
+	}
+
+	if err != nil && option.secret {
+		// the value may be anywhere in the message, never show the details
+		err = fmt.Errorf("invalid %v value %v", option.TypeHint(), SECRET_MASK)
+	}
+	return
+}
+
+// set the non-repeatable option, the glob pattern should only match one file
+func (option *FlipFlag) set_single_value(arg string) (err error) {
+	var args []string
+
+	if args, err = option.expand(arg); err != nil {
+		// cannot expand the argument
+		return
+	}
+
+	switch len(args) {
+	case 1:
+		err = option.set_value(option.Value, args[0])
+	default:
+		err = fmt.Errorf("%v matches %v files", arg, len(args))
+	}
+	return
+}
+
+// append the value to the repeatable option, the default value is replaced when first set
+func (option *FlipFlag) append_value(arg string) (err error) {
+	var args []string
+
+	slice := option.Value
+	for slice.Kind() == reflect.Ptr {
+		if slice.IsZero() {
+			// create dummy instance
+			slice.Set(reflect.New(slice.Type().Elem()))
+		}
+		slice = slice.Elem()
+	}
+
+	if option.defaulted {
+		// replace the default value
+		slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+		option.defaulted = false
+	}
+
+	if args, err = option.expand(arg); err != nil {
+		// cannot expand the argument
+		return
+	}
+
+	for _, arg := range args {
+		elm := reflect.New(slice.Type().Elem()).Elem()
+		if err = option.set_value(elm, arg); err != nil {
+			// cannot set the value
+			return
+		}
+		slice.Set(reflect.Append(slice, elm))
+	}
+	return
+}
+
+// expand the argument to multiple values, or return the argument as-is
+func (option *FlipFlag) expand(arg string) (args []string, err error) {
+	mode, glob := option.StructTag.Lookup(TAG_GLOB)
+	family, resolve := option.family()
+
+	switch {
+	case glob && HasGlobMeta(arg):
+		args, err = option.expand_glob(arg, mode)
+	case resolve && option.multiple && net.ParseIP(arg) == nil:
+		// keep all the resolved addresses in the repeatable option
+		var ips []net.IP
+
+		if ips, err = resolve_ip(option.resolver, arg, family); err != nil {
+			err = fmt.Errorf("invalid IP: %v", err)
+			return
+		}
+
+		for _, ip := range ips {
+			args = append(args, ip.String())
+		}
+	default:
+		// no-need to expand
+		args = []string{arg}
+	}
+	return
+}
+
+// expand the glob pattern by TAG_GLOB
+func (option *FlipFlag) expand_glob(arg, mode string) (args []string, err error) {
+	pattern := arg
+	if option.TypeHint() == PATH {
+		_, env := option.path_rules[PATH_ENV]
+		if pattern, err = ExpandPath(arg, env); err != nil {
+			// cannot expand the path
+			return
+		}
+	}
+
+	if args, err = Glob(pattern); err != nil {
+		err = fmt.Errorf("invalid pattern %v: %v", arg, err)
+		return
+	}
+
+	if len(args) == 0 {
+		switch mode {
+		case GLOB_STRICT:
+			err = fmt.Errorf("no matches found: %v", arg)
+		default:
+			// pass the pattern literally
+			args = []string{arg}
+		}
+	}
+	return
+}
+
+// convert the argument and set to the target, which may be the field or the element of slice
+func (option *FlipFlag) set_value(target reflect.Value, arg string) (err error) {
+	value := target
+	for value.Kind() == reflect.Ptr {
+		if value.IsZero() {
+			// create dummy instance
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if len(option.choices) > 0 {
+		idx := sort.SearchStrings(option.choices, arg)
+		if idx == len(option.choices) || option.choices[idx] != arg {
+			err = fmt.Errorf("%v not in %v", arg, option.choices)
+			return
+		}
+	}
+
+	switch option.TypeHint() {
+	case INT:
+		if num, ok := value.Addr().Interface().(*big.Int); ok {
+			var val *big.Int
+
+			if val, err = AtoBigI(arg); err != nil {
 				err = fmt.Errorf("pass %v: %v", arg, err)
 				return
 			}
-			value.SetInt(val)
-		case UINT:
-			var val uint64
+			num.Set(val)
+			break
+		}
+
+		var val int64
+
+		if val, err = AtoIUnit(arg, option.unit); err != nil {
+			err = fmt.Errorf("pass %v: %v", arg, err)
+			return
+		}
+		value.SetInt(val)
+	case UINT:
+		var val uint64
+
+		if val, err = AtoUUnit(arg, option.unit); err != nil {
+			err = fmt.Errorf("pass %#v as INT: %v", arg, err)
+			return
+		}
+		value.SetUint(val)
+	case STR:
+		// just set the raw string
+		value.Set(reflect.ValueOf(arg))
+	case RAT:
+		switch num := value.Addr().Interface().(type) {
+		case *big.Rat:
+			var val *big.Rat
+			if val, err = AtoR(arg); err != nil {
+				// cannot encode as exact rational number
+				return
+			}
 
-			if val, err = AtoU(arg); err != nil {
-				err = fmt.Errorf("pass %#v as INT: %v", arg, err)
+			num.Set(val)
+		case *big.Float:
+			var val *big.Float
+			if val, err = AtoBigF(arg); err != nil {
+				// cannot encode as arbitrary-precision float
 				return
 			}
-			value.SetUint(val)
-		case STR:
-			// just set the raw string
-			value.Set(reflect.ValueOf(arg))
-		case RAT:
+
+			num.Set(val)
+		default:
 			var val float64
 			if val, err = AtoF(arg); err != nil {
 				// cannot encode as float
@@ -148,97 +410,353 @@ func (option *FlipFlag) Set(args ...string) (count int, err error) {
 
 			// set string as Float64
 			value.SetFloat(val)
-		case FILE:
-			info, e := os.Stat(arg)
-			switch {
-			case os.IsNotExist(e):
-				err = fmt.Errorf("file %#v does not exist", value)
-				return
-			case info.IsDir():
-				err = fmt.Errorf("%#v is not file", value)
-				return
-			}
+		}
+	case FILE:
+		var std *os.File
+		var file interface{}
 
-			fd, e := os.Open(arg)
+		if std, err = check_file(arg, option.file_flag); err != nil {
+			// cannot open the file
+			return
+		}
+
+		switch {
+		case std != nil:
+			// the stdin / stdout, never closed
+			file = std
+		case value.Kind() == reflect.Interface:
+			// lazy-open the file when first used
+			lazy := NewLazyFile(arg, option.file_flag, option.file_perm)
+			option.closers = append(option.closers, lazy)
+			file = lazy
+		default:
+			fd, e := os.OpenFile(arg, option.file_flag, option.file_perm)
 			if e != nil {
-				err = fmt.Errorf("cannot open file %#v: %v", value, e)
+				err = fmt.Errorf("cannot open file %#v: %v", arg, e)
 				return
 			}
+			option.closers = append(option.closers, fd)
+			file = fd
+		}
 
-			value.Set(reflect.ValueOf(*fd))
-		case FMODE:
-			var val uint64
+		switch {
+		case value.Kind() == reflect.Interface, target.Type() == reflect.TypeOf(std):
+			// keep the *os.File or the interface
+			target.Set(reflect.ValueOf(file))
+		default:
+			value.Set(reflect.ValueOf(file).Elem())
+		}
+	case PATH:
+		var path string
+
+		_, env := option.path_rules[PATH_ENV]
+		if path, err = ExpandPath(arg, env); err != nil {
+			// cannot expand the path
+			return
+		}
 
-			val, err = AtoU(arg)
-			if err != nil || val >= (1<<32) {
-				err = fmt.Errorf("invalid file-mode: %v (%v)", value, err)
+		if _, ok := option.path_rules[PATH_ABS]; ok {
+			if path, err = filepath.Abs(path); err != nil {
+				err = fmt.Errorf("cannot get the absolute path %#v: %v", arg, err)
 				return
 			}
+		}
+
+		if err = check_path(path, option.path_rules); err != nil {
+			// not match the path rules
+			return
+		}
+
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(path)
+		default:
+			// the fs.FS
+			value.Set(reflect.ValueOf(os.DirFS(path)))
+		}
+	case FMODE:
+		var filemode os.FileMode
+
+		if filemode, err = AtoFileMode(arg); err != nil {
+			// invalid file-mode
+			return
+		}
+		value.Set(reflect.ValueOf(filemode))
+	case TIME:
+		var timestamp time.Time
+		if timestamp, err = AtoT(arg, option.layouts, option.location, option.now()); err != nil {
+			err = fmt.Errorf("invalid time: %v (%v)", arg, err)
+			return
+		}
+		value.Set(reflect.ValueOf(timestamp))
+	case SPAN:
+		var duration time.Duration
+
+		if duration, err = AtoD(arg); err != nil {
+			err = fmt.Errorf("invalid time duration: %v (%v)", arg, err)
+			return
+		}
+		value.Set(reflect.ValueOf(duration))
+	case ZONE:
+		var loc *time.Location
+
+		if loc, err = AtoLocation(arg); err != nil {
+			err = fmt.Errorf("invalid time zone: %v (%v)", arg, err)
+			return
+		}
+
+		switch {
+		case target.Type() == reflect.TypeOf(loc):
+			// keep the shared *time.Location
+			target.Set(reflect.ValueOf(loc))
+		default:
+			// NOTE - load the lazy-initialized time.Local before copy
+			_ = loc.String()
+			value.Set(reflect.ValueOf(loc).Elem())
+		}
+	case MONTH:
+		var month time.Month
+
+		if month, err = AtoMonth(arg); err != nil {
+			err = fmt.Errorf("invalid month: %v (%v)", arg, err)
+			return
+		}
+		value.Set(reflect.ValueOf(month))
+	case WEEKDAY:
+		var weekday time.Weekday
+
+		if weekday, err = AtoWeekday(arg); err != nil {
+			err = fmt.Errorf("invalid weekday: %v (%v)", arg, err)
+			return
+		}
+		value.Set(reflect.ValueOf(weekday))
+	case IFACE:
+		var iface net.Interface
+
+		if iface, err = AtoIface(arg, option.ifaces); err != nil {
+			err = fmt.Errorf("invalid IFace: %v", err)
+			return
+		}
+		value.Set(reflect.ValueOf(iface))
+	case IP:
+		family, resolve := option.family()
 
-			filemode := os.FileMode(val)
-			value.Set(reflect.ValueOf(filemode))
-		case TIME:
-			var timestamp time.Time
-			if timestamp, err = time.Parse(time.RFC3339, arg); err != nil {
-				err = fmt.Errorf("invalid time: %v (%v)", arg, err)
+		if _, ok := value.Interface().(netip.Addr); ok {
+			var addr netip.Addr
+
+			if addr, err = option.parse_addr(arg); err != nil {
+				// invalid address
 				return
 			}
-			value.Set(reflect.ValueOf(timestamp))
-		case SPAN:
-			var duration time.Duration
+			value.Set(reflect.ValueOf(addr))
+			break
+		}
 
-			if duration, err = time.ParseDuration(arg); err != nil {
-				err = fmt.Errorf("invalid time duration: %v (%v)", arg, err)
+		ip := net.ParseIP(arg)
+		switch {
+		case ip != nil:
+			if !match_family(ip, family) {
+				err = fmt.Errorf("invalid IP: %v is not %v", arg, family)
 				return
 			}
-			value.Set(reflect.ValueOf(duration))
-		case IFACE:
-			var iface *net.Interface
-			iface, err = net.InterfaceByName(arg)
-			if err != nil {
-				err = fmt.Errorf("invalid IFace: %v", arg)
+		case resolve:
+			// resolved by hostname
+			var ips []net.IP
+
+			if ips, err = resolve_ip(option.resolver, arg, family); err != nil {
+				err = fmt.Errorf("invalid IP: %v", err)
 				return
 			}
-			value.Set(reflect.ValueOf(*iface))
-		case IP:
-			ip := net.ParseIP(arg)
+			ip = ips[0]
+		default:
+			err = fmt.Errorf("invalid IP: %v", arg)
+			return
+		}
+
+		value.Set(reflect.ValueOf(ip))
+	case CIDR:
+		var prefix netip.Prefix
+
+		if prefix, err = option.parse_prefix(arg); err != nil {
+			// invalid CIDR
+			return
+		}
+
+		switch value.Interface().(type) {
+		case netip.Prefix:
+			value.Set(reflect.ValueOf(prefix))
+		default:
+			addr := prefix.Addr()
+			inet := net.IPNet{
+				IP:   net.IP(addr.AsSlice()),
+				Mask: net.CIDRMask(prefix.Bits(), addr.BitLen()),
+			}
+			value.Set(reflect.ValueOf(inet))
+		}
+	case ADDR:
+		var ip net.IP
+		var zone string
+		var port Port
+
+		network := "tcp"
+		if _, ok := value.Interface().(net.UDPAddr); ok {
+			// the service name of the UDP, like domain
+			network = "udp"
+		}
+
+		family, resolve := option.family()
+		if ip, zone, port, err = AtoHostPort(arg, network, option.resolver, resolve, family); err != nil {
+			// invalid host:port
+			return
+		}
+
+		switch value.Interface().(type) {
+		case net.TCPAddr:
+			value.Set(reflect.ValueOf(net.TCPAddr{IP: ip, Port: int(port), Zone: zone}))
+		case net.UDPAddr:
+			value.Set(reflect.ValueOf(net.UDPAddr{IP: ip, Port: int(port), Zone: zone}))
+		default:
 			if ip == nil {
-				// resoved by hostname
-				var ips []net.IP
-
-				ips, err = net.LookupIP(arg)
-				if err != nil || len(ips) == 0 {
-					err = fmt.Errorf("invalid IP: %v", arg)
-					return
-				}
-				ip = ips[0]
+				err = fmt.Errorf("invalid ADDR: %v (host is required)", arg)
+				return
 			}
 
-			value.Set(reflect.ValueOf(ip))
-		case CIDR:
-			var inet *net.IPNet
+			addr, _ := netip.AddrFromSlice(ip)
+			addr = addr.Unmap().WithZone(zone)
+			value.Set(reflect.ValueOf(netip.AddrPortFrom(addr, uint16(port))))
+		}
+	case IPSET:
+		set := value.Addr().Interface().(*IPSet)
+		if option.defaulted {
+			// replace the default value
+			*set = IPSet{}
+			option.defaulted = false
+		}
+
+		merged := &IPSet{ranges: set.Ranges()}
+		if err = merged.Add(arg); err != nil {
+			// invalid address, range or CIDR
+			return
+		}
 
-			// skip the IP field
-			if _, inet, err = net.ParseCIDR(arg); err != nil {
-				// err = fmt.Errorf("invalid CIDR: %v (%v)", value, err)
+		family, _ := option.family()
+		for _, ips := range merged.Ranges() {
+			if !match_family(net.IP(ips.First.AsSlice()), family) {
+				err = fmt.Errorf("invalid IPSET: %v is not %v", ips, family)
 				return
 			}
-			value.Set(reflect.ValueOf(*inet))
+		}
+
+		if size := merged.Size(); option.limit > 0 && size.Cmp(new(big.Int).SetUint64(option.limit)) > 0 {
+			err = fmt.Errorf("invalid IPSET: %v has %v addresses, exceeds %v", arg, size, option.limit)
+			return
+		}
+		*set = *merged
+	case REGEXP:
+		var re *regexp.Regexp
+
+		if re, err = regexp.Compile(arg); err != nil {
+			err = fmt.Errorf("invalid REGEXP: %v", err)
+			return
+		}
+		switch {
+		case target.Type() == reflect.TypeOf(re):
+			// keep the compiled *regexp.Regexp
+			target.Set(reflect.ValueOf(re))
 		default:
-			err = fmt.Errorf("not implemented set %v", option.TypeHint())
+			value.Set(reflect.ValueOf(re).Elem())
+		}
+	case TMPL:
+		var tmpl *template.Template
+
+		if tmpl, err = template.New(option.Name()).Funcs(option.funcs).Parse(arg); err != nil {
+			err = fmt.Errorf("invalid TMPL: %v", err)
 			return
 		}
-		count++
+		// keep the compiled *template.Template, the TMPL is always the pointer
+		target.Set(reflect.ValueOf(tmpl))
+	case BYTES:
+		var data []byte
+
+		if data, err = read_bytes(arg, option.StructTag.Get(TAG_ENCODING), option.stdin); err != nil {
+			err = fmt.Errorf("invalid BYTES: %v", err)
+			return
+		}
+
+		switch size := uint64(len(data)); {
+		case value.Kind() == reflect.Array && len(data) != value.Len():
+			err = fmt.Errorf("invalid BYTES: expect %v bytes, got %v", value.Len(), len(data))
+			return
+		case option.max_len > 0 && (size < option.min_len || size > option.max_len):
+			err = fmt.Errorf("invalid BYTES: length %v out of range %v", size, option.StructTag.Get(TAG_LEN))
+			return
+		}
+
+		switch value.Kind() {
+		case reflect.Array:
+			reflect.Copy(value, reflect.ValueOf(data))
+		default:
+			value.SetBytes(data)
+		}
+	case JSON:
+		// decode into the fresh instance, not merge with the previous value
+		decoded := reflect.New(value.Type())
+		if err = json.Unmarshal([]byte(arg), decoded.Interface()); err != nil {
+			err = fmt.Errorf("invalid JSON: %v", err)
+			return
+		}
+		value.Set(decoded.Elem())
+	case URL:
+		var link *url.URL
+
+		if link, err = url.Parse(arg); err != nil {
+			err = fmt.Errorf("invalid URL: %v", arg)
+			return
+		}
+
+		if schemes := strings.Fields(option.StructTag.Get(TAG_SCHEME)); len(schemes) > 0 {
+			allowed := false
+			for _, scheme := range schemes {
+				allowed = allowed || strings.EqualFold(scheme, link.Scheme)
+			}
+
+			if !allowed {
+				err = fmt.Errorf("invalid URL: %v scheme should be %v", arg, schemes)
+				return
+			}
+		}
+		value.Set(reflect.ValueOf(*link))
+	case MAC:
+		var mac net.HardwareAddr
+
+		if mac, err = net.ParseMAC(arg); err != nil {
+			err = fmt.Errorf("invalid MAC: %v", arg)
+			return
+		}
+		value.Set(reflect.ValueOf(mac))
+	case PORT:
+		switch value.Interface().(type) {
+		case Port:
+			var port Port
+
+			if port, err = AtoPort(arg, "tcp"); err != nil {
+				// invalid port
+				return
+			}
+			value.Set(reflect.ValueOf(port))
+		default:
+			var ports PortRange
+
+			if ports, err = AtoPortRange(arg); err != nil {
+				// invalid port or port range
+				return
+			}
+			value.Set(reflect.ValueOf(ports))
+		}
 	default:
-		err = fmt.Errorf("should not be here: %v", option.Type())
+		err = fmt.Errorf("not implemented set %v", option.TypeHint())
 		return
 	}
-
-	if option.Callback != nil {
-		// call the callback
-		log.Trace("execute callback %v", option.Callback)
-		option.Callback(option)
-	}
 	return
 }
 
@@ -260,6 +778,178 @@ func (option *FlipFlag) SetCallback(fn Callback) {
 	option.Callback = fn
 }
 
+// the address family by TAG_RESOLVE or TAG_FAMILY, and the hostname can be resolved or not
+func (option *FlipFlag) family() (family string, resolve bool) {
+	family, resolve = option.StructTag.Lookup(TAG_RESOLVE)
+	if family == "" || family == RESOLVE_IP {
+		// fallback to the family tag
+		family = option.StructTag.Get(TAG_FAMILY)
+	}
+	return
+}
+
+// parse the netip.Addr, or resolve the hostname if TAG_RESOLVE is set
+func (option *FlipFlag) parse_addr(arg string) (addr netip.Addr, err error) {
+	family, resolve := option.family()
+
+	addr, err = netip.ParseAddr(arg)
+	switch {
+	case err == nil:
+		if !match_family(net.IP(addr.AsSlice()), family) {
+			err = fmt.Errorf("invalid IP: %v is not %v", arg, family)
+			return
+		}
+	case resolve:
+		// resolved by hostname
+		var ips []net.IP
+
+		if ips, err = resolve_ip(option.resolver, arg, family); err != nil {
+			err = fmt.Errorf("invalid IP: %v", err)
+			return
+		}
+
+		addr, _ = netip.AddrFromSlice(ips[0])
+		addr = addr.Unmap()
+	default:
+		err = fmt.Errorf("invalid IP: %v", arg)
+		return
+	}
+	return
+}
+
+// parse the CIDR by the TAG_CIDR mode, and mask the host part by default
+func (option *FlipFlag) parse_prefix(arg string) (prefix netip.Prefix, err error) {
+	if prefix, err = netip.ParsePrefix(arg); err != nil {
+		err = fmt.Errorf("invalid CIDR: %v", arg)
+		return
+	}
+
+	if family, _ := option.family(); !match_family(net.IP(prefix.Addr().AsSlice()), family) {
+		err = fmt.Errorf("invalid CIDR: %v is not %v", arg, family)
+		return
+	}
+
+	switch option.StructTag.Get(TAG_CIDR) {
+	case CIDR_HOST:
+		// keep the host address
+	case CIDR_NETWORK:
+		if masked := prefix.Masked(); masked != prefix {
+			err = fmt.Errorf("invalid CIDR: %v is not the network address, expect %v", arg, masked)
+			return
+		}
+	default:
+		prefix = prefix.Masked()
+	}
+	return
+}
+
+// the candidates of the value which has the prefix, used in completion
+func (option *FlipFlag) complete(prefix string) (candidates []string) {
+	if len(option.choices) > 0 {
+		for _, choice := range option.choices {
+			if strings.HasPrefix(choice, prefix) {
+				// the matched choice
+				candidates = append(candidates, choice)
+			}
+		}
+		return
+	}
+
+	switch option.TypeHint() {
+	case URL:
+		for _, scheme := range strings.Fields(option.StructTag.Get(TAG_SCHEME)) {
+			if candidate := scheme + "://"; strings.HasPrefix(candidate, prefix) {
+				// the allowed scheme
+				candidates = append(candidates, candidate)
+			}
+		}
+	case IFACE:
+		ifaces, _ := option.ifaces.Interfaces()
+		for _, iface := range ifaces {
+			if strings.HasPrefix(iface.Name, prefix) {
+				// the network interface name
+				candidates = append(candidates, iface.Name)
+			}
+		}
+	case FILE:
+		candidates = complete_path(prefix, false, false)
+	case PATH:
+		_, dir_only := option.path_rules[PATH_DIR]
+		_, env := option.path_rules[PATH_ENV]
+		candidates = complete_path(prefix, dir_only, env)
+	}
+	return
+}
+
+// format the value as the human-readable string, used in the default value
+func (option *FlipFlag) format_value(value reflect.Value) (str string) {
+	if option.multiple && value.Kind() == reflect.Slice {
+		var values []string
+
+		for idx := 0; idx < value.Len(); idx++ {
+			// format each element
+			values = append(values, option.format_value(value.Index(idx)))
+		}
+		str = strings.Join(values, " ")
+		return
+	}
+
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		// the element of the slice may be the pointer
+		value = value.Elem()
+	}
+
+	if stringer, ok := value.Addr().Interface().(fmt.Stringer); ok && value.Kind() == reflect.Struct {
+		// the struct value which has the String() method, like big.Rat
+		str = stringer.String()
+		return
+	}
+
+	switch option.TypeHint() {
+	case INT:
+		str = ItoA(value.Int(), option.unit)
+	case UINT:
+		str = UtoA(value.Uint(), option.unit)
+	case TIME:
+		str = TtoA(value.Interface().(time.Time), option.layouts)
+	case SPAN:
+		str = DtoA(time.Duration(value.Int()))
+	case BYTES:
+		// never show the bytes, like the key
+		str = fmt.Sprintf("<%v bytes>", value.Len())
+	case TMPL:
+		if tmpl := value.Addr().Interface().(*template.Template); tmpl.Tree != nil {
+			// show the parsed template
+			str = tmpl.Tree.Root.String()
+		}
+	case JSON:
+		if data, ok := value.Interface().(json.RawMessage); ok {
+			// show the raw JSON
+			str = string(data)
+			break
+		}
+
+		data, _ := json.Marshal(value.Interface())
+		str = string(data)
+	case FILE:
+		file := value.Interface()
+		if value.Kind() == reflect.Struct {
+			// the os.File
+			file = value.Addr().Interface()
+		}
+
+		if named, ok := file.(interface{ Name() string }); ok {
+			// show the path of the file
+			str = named.Name()
+			break
+		}
+		str = fmt.Sprintf("%v", value)
+	default:
+		str = fmt.Sprintf("%v", value)
+	}
+	return
+}
+
 func (opt *FlipFlag) IsRequired() (required bool) {
 	required = opt.required
 	return
@@ -1,9 +1,10 @@
 module github.com/cmj0121/structopt
 
-go 1.16
+go 1.18
+
+require golang.org/x/text v0.3.6
 
 require (
 	github.com/cmj0121/logger v1.3.4 // indirect
-	golang.org/x/text v0.3.6 // indirect
 	golang.org/x/tools v0.1.5 // indirect
 )
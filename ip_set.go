@@ -0,0 +1,204 @@
+package structopt
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// The inclusive range of the IP address, like 10.0.0.1-10.0.0.50
+type IPRange struct {
+	First netip.Addr
+	Last  netip.Addr
+}
+
+// The IP address is in the range or not
+func (ips IPRange) Contains(addr netip.Addr) (ok bool) {
+	ok = ips.First.Compare(addr) <= 0 && addr.Compare(ips.Last) <= 0
+	return
+}
+
+// The number of the addresses in the range
+func (ips IPRange) Size() (size *big.Int) {
+	first := new(big.Int).SetBytes(ips.First.AsSlice())
+	last := new(big.Int).SetBytes(ips.Last.AsSlice())
+
+	size = last.Sub(last, first)
+	size.Add(size, big.NewInt(1))
+	return
+}
+
+// Show the range, like 10.0.0.1-10.0.0.50 or 10.0.0.1
+func (ips IPRange) String() (str string) {
+	str = fmt.Sprintf("%v-%v", ips.First, ips.Last)
+	if ips.First == ips.Last {
+		// the single address
+		str = ips.First.String()
+	}
+	return
+}
+
+// The set of the IP addresses, parsed from the address, range and CIDR (separate by comma),
+// like 10.0.0.1-10.0.0.50,192.168.1.0/30. The ranges are sorted and merged.
+type IPSet struct {
+	ranges []IPRange
+}
+
+// Parse the IP set from the address, range and CIDR (separate by comma)
+func ParseIPSet(s string) (set *IPSet, err error) {
+	set = &IPSet{}
+	err = set.Add(s)
+	return
+}
+
+// Add the address, range and CIDR (separate by comma) into the set
+func (set *IPSet) Add(s string) (err error) {
+	var ranges []IPRange
+
+	for _, token := range strings.Split(s, ",") {
+		var ips IPRange
+
+		if ips, err = AtoIPRange(strings.TrimSpace(token)); err != nil {
+			// invalid address, range or CIDR
+			return
+		}
+		ranges = append(ranges, ips)
+	}
+
+	set.ranges = merge_ranges(append(set.ranges, ranges...))
+	return
+}
+
+// The sorted and merged ranges in the set
+func (set *IPSet) Ranges() (ranges []IPRange) {
+	ranges = append(ranges, set.ranges...)
+	return
+}
+
+// The number of the addresses in the set
+func (set *IPSet) Size() (size *big.Int) {
+	size = new(big.Int)
+	for _, ips := range set.ranges {
+		size.Add(size, ips.Size())
+	}
+	return
+}
+
+// The IP address is in the set or not
+func (set *IPSet) Contains(addr netip.Addr) (ok bool) {
+	for _, ips := range set.ranges {
+		if ips.Contains(addr) {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// Iterate the addresses in the set by order, and stop when fn return false
+func (set *IPSet) Range(fn func(addr netip.Addr) bool) {
+	for _, ips := range set.ranges {
+		for addr := ips.First; addr.IsValid() && addr.Compare(ips.Last) <= 0; addr = addr.Next() {
+			if !fn(addr) {
+				// stop the iteration
+				return
+			}
+		}
+	}
+}
+
+// Show the set as the ranges separate by comma
+func (set *IPSet) String() (str string) {
+	var ranges []string
+
+	for _, ips := range set.ranges {
+		ranges = append(ranges, ips.String())
+	}
+	str = strings.Join(ranges, ",")
+	return
+}
+
+// the IP range from the address (10.0.0.1), range (10.0.0.1-10.0.0.50 or 10.0.0.1-50) or CIDR
+func AtoIPRange(s string) (ips IPRange, err error) {
+	switch {
+	case strings.Contains(s, "/"):
+		var prefix netip.Prefix
+
+		if prefix, err = netip.ParsePrefix(s); err != nil {
+			err = fmt.Errorf("invalid CIDR: %v", s)
+			return
+		}
+
+		prefix = prefix.Masked()
+		ips.First = prefix.Addr()
+		ips.Last = last_addr(prefix)
+	case strings.Contains(s, "-"):
+		idx := strings.Index(s, "-")
+		if ips.First, err = netip.ParseAddr(s[:idx]); err != nil {
+			err = fmt.Errorf("invalid IP range: %v", s)
+			return
+		}
+
+		last := s[idx+1:]
+		if ips.First.Is4() && !strings.Contains(last, ".") {
+			// the short form, replace the last octet
+			last = s[:strings.LastIndex(s[:idx], ".")+1] + last
+		}
+
+		if ips.Last, err = netip.ParseAddr(last); err != nil {
+			err = fmt.Errorf("invalid IP range: %v", s)
+			return
+		}
+	default:
+		if ips.First, err = netip.ParseAddr(s); err != nil {
+			err = fmt.Errorf("invalid IP: %v", s)
+			return
+		}
+		ips.Last = ips.First
+	}
+
+	switch {
+	case ips.First.Is4() != ips.Last.Is4():
+		err = fmt.Errorf("invalid IP range: %v mixed IPv4 and IPv6", s)
+	case ips.First.Compare(ips.Last) > 0:
+		err = fmt.Errorf("invalid IP range: %v", s)
+	}
+	return
+}
+
+// the last address of the CIDR, set all the host bits
+func last_addr(prefix netip.Prefix) (addr netip.Addr) {
+	bytes := prefix.Addr().AsSlice()
+	for bit := prefix.Bits(); bit < len(bytes)*8; bit++ {
+		bytes[bit/8] |= 0x80 >> (bit % 8)
+	}
+
+	addr, _ = netip.AddrFromSlice(bytes)
+	return
+}
+
+// sort and merge the overlapped or adjacent ranges
+func merge_ranges(ranges []IPRange) (merged []IPRange) {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].First.Compare(ranges[j].First) < 0
+	})
+
+	for _, ips := range ranges {
+		if len(merged) > 0 {
+			last := &merged[len(merged)-1]
+			if next := last.Last.Next(); !next.IsValid() || ips.First.Compare(next) <= 0 {
+				if ips.First.Is4() == last.First.Is4() {
+					if ips.Last.Compare(last.Last) > 0 {
+						// extend the range
+						last.Last = ips.Last
+					}
+					continue
+				}
+			}
+		}
+		merged = append(merged, ips)
+	}
+	return
+}
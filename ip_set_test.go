@@ -0,0 +1,87 @@
+package structopt
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIPSet(t *testing.T) {
+	cases := map[string]string{
+		"10.0.0.1":                              "10.0.0.1",
+		"10.0.0.1-10.0.0.50":                    "10.0.0.1-10.0.0.50",
+		"10.0.0.1-50":                           "10.0.0.1-10.0.0.50",
+		"192.168.1.5/30":                        "192.168.1.4-192.168.1.7",
+		"10.0.0.5,10.0.0.1-4,10.0.0.3-10.0.0.8": "10.0.0.1-10.0.0.8",
+		"2001:db8::/127, 10.0.0.1":              "10.0.0.1,2001:db8::-2001:db8::1",
+		"255.255.255.255,::/128":                "255.255.255.255,::",
+	}
+
+	for s, ans := range cases {
+		set, err := ParseIPSet(s)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot parse %v: %v", s, err)
+		case set.String() != ans:
+			t.Errorf("ParseIPSet(%v) = %v: %v", s, set, ans)
+		}
+	}
+
+	for _, s := range []string{"", "10.0.0.1-", "10.0.0.5-1", "10.0.0.1-::1", "10.0.0.0/33", "host"} {
+		if set, err := ParseIPSet(s); err == nil {
+			// expect failure
+			t.Errorf("expect cannot parse %#v: %v", s, set)
+		}
+	}
+
+	set, _ := ParseIPSet("10.0.0.1-3,192.168.1.0/31")
+	var addrs []string
+	set.Range(func(addr netip.Addr) bool {
+		addrs = append(addrs, addr.String())
+		return len(addrs) < 4
+	})
+
+	switch {
+	case set.Size().Int64() != 5:
+		t.Errorf("expect 5 addresses: %v", set.Size())
+	case len(addrs) != 4 || addrs[3] != "192.168.1.0":
+		t.Errorf("expect iterate 4 addresses: %v", addrs)
+	case !set.Contains(netip.MustParseAddr("10.0.0.2")) || set.Contains(netip.MustParseAddr("10.0.0.4")):
+		t.Errorf("expect contains 10.0.0.2 but not 10.0.0.4: %v", set)
+	}
+}
+
+type Scan struct {
+	Targets IPSet    `short:"t" limit:"256" default:"127.0.0.1"`
+	Exclude []IPSet  `family:"ip4"`
+	Extra   *[]IPSet `help:"the extra targets"`
+}
+
+func TestIPSetOption(t *testing.T) {
+	scan := Scan{}
+	parser := MustNew(&scan)
+
+	args := []string{"-t", "10.0.0.1-10.0.0.50", "-t", "192.168.1.0/30", "--exclude", "10.0.0.2,10.0.0.3", "172.16.0.1", "172.16.0.2"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case scan.Targets.String() != "10.0.0.1-10.0.0.50,192.168.1.0-192.168.1.3":
+		t.Errorf("expect --targets replace the default and accumulate: %v", &scan.Targets)
+	case len(scan.Exclude) != 1 || scan.Exclude[0].String() != "10.0.0.2-10.0.0.3":
+		t.Errorf("expect --exclude: %v", scan.Exclude)
+	case scan.Extra == nil || len(*scan.Extra) != 2:
+		t.Errorf("expect EXTRA: %v", scan.Extra)
+	}
+
+	cases := [][]string{
+		{"-t", "10.1.0.0/24"},
+		{"--exclude", "::1"},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
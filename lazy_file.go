@@ -0,0 +1,87 @@
+package structopt
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// The lazy-opened file set in the io.Reader / io.Writer option, which only open
+// the file when first used and should be closed by StructOpt.Close.
+type LazyFile struct {
+	// the path of the file
+	path string
+	// the os.OpenFile flag and permission
+	flag int
+	perm os.FileMode
+
+	once sync.Once
+	file *os.File
+	err  error
+}
+
+// Create the lazy-opened file, same as os.OpenFile but open when first used.
+func NewLazyFile(path string, flag int, perm os.FileMode) (file *LazyFile) {
+	file = &LazyFile{
+		path: path,
+		flag: flag,
+		perm: perm,
+	}
+	return
+}
+
+// The path of the file
+func (file *LazyFile) Name() (name string) {
+	name = file.path
+	return
+}
+
+// Open the file if not opened, and return the opened os.File.
+func (file *LazyFile) File() (fd *os.File, err error) {
+	file.once.Do(func() {
+		log.Debug("lazy open %v (flag: %v, perm: %v)", file.path, file.flag, file.perm)
+		if file.file, file.err = os.OpenFile(file.path, file.flag, file.perm); file.err != nil {
+			file.err = fmt.Errorf("cannot open file %#v: %v", file.path, file.err)
+		}
+	})
+
+	fd, err = file.file, file.err
+	return
+}
+
+// Read from the file, open the file when first used.
+func (file *LazyFile) Read(p []byte) (n int, err error) {
+	var fd *os.File
+
+	if fd, err = file.File(); err != nil {
+		// cannot open the file
+		return
+	}
+	n, err = fd.Read(p)
+	return
+}
+
+// Write to the file, open the file when first used.
+func (file *LazyFile) Write(p []byte) (n int, err error) {
+	var fd *os.File
+
+	if fd, err = file.File(); err != nil {
+		// cannot open the file
+		return
+	}
+	n, err = fd.Write(p)
+	return
+}
+
+// Close the file if opened, and the file cannot be opened after closed.
+func (file *LazyFile) Close() (err error) {
+	file.once.Do(func() {
+		// never open the file after closed
+		file.err = fmt.Errorf("file %#v already closed", file.path)
+	})
+
+	if file.file != nil {
+		err = file.file.Close()
+	}
+	return
+}
@@ -0,0 +1,93 @@
+package structopt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type Copy struct {
+	Input  io.Reader `short:"i" default:"-" help:"the input file"`
+	Output io.Writer `short:"o" help:"the output file"`
+	Log    *os.File  `option:"flag" mode:"append create" perm:"0600"`
+
+	Source *os.File `help:"the source file"`
+}
+
+func TestFileOption(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	output := filepath.Join(dir, "output")
+	logfile := filepath.Join(dir, "log")
+
+	if err := ioutil.WriteFile(source, []byte("hello"), 0644); err != nil {
+		t.Fatalf("cannot create %v: %v", source, err)
+	}
+
+	cp := Copy{}
+	parser := MustNew(&cp)
+	defer parser.Close()
+
+	if cp.Input != os.Stdin {
+		t.Errorf("expect default --input is stdin: %v", cp.Input)
+	}
+
+	if _, err := parser.Set("-i", source, "-o", output, "--log", logfile, source); err != nil {
+		t.Fatalf("cannot set the file: %v", err)
+	}
+
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("expect %v lazy created: %v", output, err)
+	}
+
+	if _, err := io.Copy(cp.Output, cp.Input); err != nil {
+		t.Fatalf("cannot copy %v to %v: %v", source, output, err)
+	}
+
+	if info, err := os.Stat(logfile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expect %v created as 0600: %v", logfile, err)
+	}
+
+	if cp.Source == nil || cp.Source.Name() != source {
+		t.Errorf("expect SOURCE opened: %v", cp.Source)
+	}
+
+	if err := parser.Close(); err != nil {
+		t.Fatalf("cannot close the files: %v", err)
+	}
+
+	if data, err := ioutil.ReadFile(output); err != nil || string(data) != "hello" {
+		t.Errorf("expect %v written: %#v (%v)", output, string(data), err)
+	}
+
+	if _, err := cp.Output.Write([]byte("!")); err == nil {
+		t.Errorf("expect %v cannot write after closed", output)
+	}
+}
+
+func TestFileOptionFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	cp := Copy{}
+	parser := MustNew(&cp)
+	defer parser.Close()
+
+	if _, err := parser.Set("-"); err != nil || cp.Source != os.Stdin {
+		t.Errorf("expect SOURCE - as stdin: %v (%v)", cp.Source, err)
+	}
+
+	cases := [][]string{
+		{"-i", filepath.Join(dir, "not-exist")},
+		{"-i", dir},
+		{"--log", dir},
+	}
+
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
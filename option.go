@@ -46,6 +46,32 @@ const (
 	IP
 	// the network IPv4 / IPv6 address with mask, CIDR
 	CIDR
+	// the time zone, time.Location
+	ZONE
+	// the month of the year, time.Month
+	MONTH
+	// the day of the week, time.Weekday
+	WEEKDAY
+	// the path which not opened, string or fs.FS
+	PATH
+	// the IP address with port, like 127.0.0.1:8080
+	ADDR
+	// the URL, like https://example.com/path
+	URL
+	// the hardware address, like 00:00:5e:00:53:01
+	MAC
+	// the port or port range, like 8080 or 8000-8100
+	PORT
+	// the set of IP address, range and CIDR, like 10.0.0.1-10.0.0.50,192.168.1.0/30
+	IPSET
+	// the regular expression, *regexp.Regexp
+	REGEXP
+	// the text template, *template.Template
+	TMPL
+	// the JSON value, json.RawMessage or any field with the json option
+	JSON
+	// the byte slice, decoded by the encoding
+	BYTES
 )
 
 // The callback function which is used when option been set
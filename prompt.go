@@ -0,0 +1,120 @@
+package structopt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the stdin can be asked, which is the terminal or the supplied reader
+func (opt *StructOpt) interactive() (ok bool) {
+	switch file, is_file := opt.stdin.(*os.File); {
+	case !is_file:
+		// the supplied reader, like the input of the test
+		ok = true
+	default:
+		ok = is_terminal(file)
+	}
+	return
+}
+
+// ask the value of the missing required option until it is set, the help text is shown as the
+// question and the choices are shown as the menu. Return false if the option is not set.
+func (opt *StructOpt) prompt_missing(option Option) (ok bool) {
+	flip, is_flip := option.(*FlipFlag)
+	if !is_flip || flip.Type() == Flip || !opt.prompt || !opt.interactive() {
+		// only the flag and argument has the value to ask
+		return
+	}
+
+	if help := flip.Get(TAG_HELP); help != "" {
+		// show the help as the question
+		fmt.Fprintln(opt.stderr, help)
+	}
+
+	for idx, choice := range flip.choices {
+		switch {
+		case flip.numbered_menu():
+			// show the choices as the menu
+			fmt.Fprintf(opt.stderr, "  %d) %v\n", idx+1, choice)
+		default:
+			// the number of the menu is ambiguous with the numeric choice
+			fmt.Fprintf(opt.stderr, "  - %v\n", choice)
+		}
+	}
+
+	// the value before asking, restored when the answer is rejected
+	initial, defaulted := clone_value(flip.Value), flip.defaulted
+	for retry := 0; retry < PROMPT_RETRY; retry++ {
+		var err error
+
+		switch {
+		case flip.secret:
+			// ask without echo, and never show the secret in the error
+			_, err = flip.Set(SECRET_PROMPT)
+		default:
+			var answer string
+
+			fmt.Fprintf(opt.stderr, "%v: ", flip.Name())
+			if answer, err = read_line(opt.stdin); err != nil {
+				// cannot read any more
+				fmt.Fprintln(opt.stderr)
+				return
+			}
+
+			err = flip.set_answer(answer)
+		}
+
+		if err == nil && !flip.IsZero() {
+			ok = true
+			return
+		}
+
+		if err == nil {
+			err = fmt.Errorf("%v should not be empty", flip.Name())
+		}
+		fmt.Fprintf(opt.stderr, "error: %v\n", err)
+		// drop the valid words of the rejected answer in the repeatable option
+		flip.Value.Set(clone_value(initial))
+		flip.defaulted = defaulted
+	}
+	return
+}
+
+// set the answer of the prompt, the number of the menu is the choice and the repeatable
+// option is split by the shell quoting rules
+func (option *FlipFlag) set_answer(answer string) (err error) {
+	answers := []string{strings.TrimSpace(answer)}
+	if option.multiple {
+		if answers, err = SplitWords(answer); err != nil {
+			return
+		}
+	}
+
+	for _, answer := range answers {
+		idx, invalid := strconv.Atoi(answer)
+		if invalid == nil && idx > 0 && idx <= len(option.choices) && option.numbered_menu() {
+			// the number of the menu
+			answer = option.choices[idx-1]
+		}
+
+		if _, err = option.Set(answer); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// the choices are shown as the numbered menu, unless any choice is the number
+func (option *FlipFlag) numbered_menu() (ok bool) {
+	for _, choice := range option.choices {
+		if _, err := strconv.Atoi(choice); err == nil {
+			// ambiguous with the number of the menu
+			return
+		}
+	}
+
+	ok = true
+	return
+}
@@ -0,0 +1,100 @@
+package structopt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Rollout struct {
+	Region   string   `option:"required" choice:"us eu ap" help:"the region to deploy"`
+	Replicas int      `option:"required"`
+	Token    string   `option:"required,secret"`
+	Labels   []string `option:"required"`
+	Verbose  bool     `option:"required"`
+	Target   *string
+}
+
+func TestPromptRequired(t *testing.T) {
+	rollout := Rollout{}
+	parser := MustNew(&rollout)
+	stderr := &strings.Builder{}
+	parser.SetStderr(stderr)
+	parser.SetStdin(strings.NewReader("9\n2\nmany\n3\ns3cret\nweb 'db primary'\n-v\n"))
+
+	if _, err := parser.Set("--verbose"); err == nil || !strings.Contains(err.Error(), "--region is required") {
+		t.Fatalf("expect never prompt by default: %v", err)
+	}
+
+	parser.SetPrompt(true)
+	if _, err := parser.Set(); err != nil {
+		t.Fatalf("cannot prompt the missing options: %v (%v)", err, stderr)
+	}
+
+	switch {
+	case rollout.Region != "eu" || rollout.Replicas != 3:
+		t.Errorf("expect --region and --replicas from the prompt: %v %v", rollout.Region, rollout.Replicas)
+	case rollout.Token != "s3cret":
+		t.Errorf("expect --token from the prompt: %v", rollout.Token)
+	case len(rollout.Labels) != 2 || rollout.Labels[1] != "db primary":
+		t.Errorf("expect --labels split by the quoting rules: %#v", rollout.Labels)
+	case rollout.Target == nil || *rollout.Target != "-v":
+		t.Errorf("expect TARGET from the prompt: %v", rollout.Target)
+	}
+
+	output := stderr.String()
+	switch {
+	case !strings.Contains(output, "the region to deploy\n  1) ap\n  2) eu\n  3) us\n"):
+		t.Errorf("expect show the help and the menu: %v", output)
+	case strings.Count(output, "error: ") != 2:
+		t.Errorf("expect retry the invalid answers: %v", output)
+	case strings.Contains(output, "s3cret"):
+		t.Errorf("expect never show the secret: %v", output)
+	}
+
+	parser = MustNew(&Rollout{})
+	parser.SetPrompt(true)
+	parser.SetStderr(&strings.Builder{})
+	parser.SetStdin(strings.NewReader("eu\n"))
+	if _, err := parser.Set(); err == nil || !strings.Contains(err.Error(), "--replicas is required") {
+		t.Errorf("expect stop asking at the end of input: %v", err)
+	}
+}
+
+type Shard struct {
+	Count string `option:"required" choice:"1 2 4 8"`
+}
+
+func TestPromptNumericChoice(t *testing.T) {
+	for answer, count := range map[string]string{"4\n": "4", "8\n": "8", "3\n2\n": "2"} {
+		shard := Shard{}
+		parser := MustNew(&shard)
+		parser.SetPrompt(true)
+		stderr := &strings.Builder{}
+		parser.SetStderr(stderr)
+		parser.SetStdin(strings.NewReader(answer))
+
+		switch _, err := parser.Set(); {
+		case err != nil || shard.Count != count:
+			t.Errorf("expect answer %#v as %v: %v (%v)", answer, count, shard.Count, err)
+		case !strings.Contains(stderr.String(), "  - 1\n  - 2\n  - 4\n  - 8\n"):
+			t.Errorf("expect never number the numeric choices: %v", stderr)
+		}
+	}
+}
+
+type Release struct {
+	Labels []string `option:"required" choice:"a b"`
+}
+
+func TestPromptRetryRepeatable(t *testing.T) {
+	release := Release{}
+	parser := MustNew(&release)
+	parser.SetPrompt(true)
+	parser.SetStderr(&strings.Builder{})
+	parser.SetStdin(strings.NewReader("a x\nb\n"))
+
+	if _, err := parser.Set(); err != nil || !reflect.DeepEqual(release.Labels, []string{"b"}) {
+		t.Errorf("expect drop the rejected answer: %v (%v)", release.Labels, err)
+	}
+}
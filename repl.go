@@ -0,0 +1,217 @@
+package structopt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pre-define the built-in commands of the REPL
+const (
+	REPL_HELP    = "help"
+	REPL_HISTORY = "history"
+	REPL_EXIT    = "exit"
+	REPL_QUIT    = "quit"
+)
+
+// The interactive shell which read the lines, parse each line as the arguments of the fresh
+// instance of the struct, and then run the handler with the parsed instance.
+//
+// The line ends with the tab shows the completion, !! and !N re-run the command in the
+// history, and help, history and exit are the built-in commands.
+type REPL struct {
+	// The prompt shown before each line
+	Prompt string
+
+	// the parser used to generate the fresh instance, never set
+	opt *StructOpt
+	// run the parsed instance, like *Struct
+	handler func(cmd interface{}) error
+	// the executed lines
+	history []string
+}
+
+// Generate the REPL which parse the line by the same struct of the StructOpt, and run the handler.
+func (opt *StructOpt) REPL(handler func(cmd interface{}) error) (repl *REPL) {
+	repl = &REPL{
+		Prompt: fmt.Sprintf("%v> ", opt.Name()),
+
+		opt:     opt,
+		handler: handler,
+	}
+	return
+}
+
+// Run the REPL until the EOF or the exit command, the output and error are written to w.
+func (repl *REPL) Run(r io.Reader, w io.Writer) (err error) {
+	for {
+		var line string
+
+		fmt.Fprint(w, repl.Prompt)
+		switch line, err = read_line(r); {
+		case err == io.EOF:
+			// end of the input
+			fmt.Fprintln(w)
+			err = nil
+			return
+		case err != nil:
+			return
+		}
+
+		if strings.HasSuffix(line, "\t") {
+			// show the completion
+			fmt.Fprintln(w, strings.Join(repl.Complete(strings.TrimSuffix(line, "\t")), " "))
+			continue
+		}
+
+		if exit := repl.Execute(line, r, w); exit {
+			// the exit command
+			return
+		}
+	}
+}
+
+// Execute the single line and write the output and error to w, the confirmation and the prompt
+// are read from r. Return true when the exit command.
+func (repl *REPL) Execute(line string, r io.Reader, w io.Writer) (exit bool) {
+	var args []string
+	var err error
+
+	if line, err = repl.expand_history(strings.TrimSpace(line)); err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
+	}
+
+	if args, err = SplitWords(line); err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+		return
+	}
+
+	if len(args) == 0 {
+		// empty line
+		return
+	}
+	repl.history = append(repl.history, line)
+
+	switch args[0] {
+	case REPL_EXIT, REPL_QUIT:
+		exit = true
+	case REPL_HELP:
+		repl.help(args[1:], w)
+	case REPL_HISTORY:
+		for idx, line := range repl.history {
+			fmt.Fprintf(w, "%5d  %v\n", idx+1, line)
+		}
+	default:
+		if err = repl.run(args, r, w); err != nil {
+			fmt.Fprintf(w, "error: %v\n", err)
+		}
+	}
+	return
+}
+
+// The candidates of the last word in the line, the built-in commands are also completed.
+func (repl *REPL) Complete(line string) (candidates []string) {
+	args, err := SplitWords(line)
+	if err != nil {
+		// cannot complete the unbalanced quote
+		return
+	}
+
+	if len(args) == 0 || strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+		// complete the new word
+		args = append(args, "")
+	}
+
+	candidates = repl.opt.Complete(args...)
+	if len(args) == 1 {
+		for _, builtin := range []string{REPL_EXIT, REPL_HELP, REPL_HISTORY, REPL_QUIT} {
+			if strings.HasPrefix(builtin, args[0]) {
+				// the built-in command
+				candidates = append(candidates, builtin)
+			}
+		}
+		sort.Strings(candidates)
+	}
+	return
+}
+
+// The executed lines
+func (repl *REPL) History() (history []string) {
+	history = append(history, repl.history...)
+	return
+}
+
+// re-run the last command (!!) or the N-th command (!N) in the history
+func (repl *REPL) expand_history(line string) (expanded string, err error) {
+	expanded = line
+
+	switch {
+	case line == "!!":
+		if len(repl.history) == 0 {
+			err = fmt.Errorf("no command in the history")
+			return
+		}
+		expanded = repl.history[len(repl.history)-1]
+	case strings.HasPrefix(line, "!") && len(line) > 1:
+		var idx int
+
+		if idx, err = strconv.Atoi(line[1:]); err != nil || idx < 1 || idx > len(repl.history) {
+			err = fmt.Errorf("no such command in the history: %v", line)
+			return
+		}
+		expanded = repl.history[idx-1]
+	}
+	return
+}
+
+// show the usage of the parser, or the sub-command
+func (repl *REPL) help(args []string, w io.Writer) {
+	opt := repl.opt
+	for _, name := range args {
+		sub, ok := opt.named_options[name].(*StructOpt)
+		if !ok {
+			fmt.Fprintf(w, "error: unknown sub-command: %v\n", name)
+			return
+		}
+		opt = sub
+	}
+
+	fmt.Fprint(w, opt.Usage())
+}
+
+// parse the arguments by the fresh instance, and then run the handler
+func (repl *REPL) run(args []string, r io.Reader, w io.Writer) (err error) {
+	var fork *StructOpt
+
+	if fork, err = repl.opt.Fork(); err != nil {
+		// cannot generate the fresh instance
+		return
+	}
+	defer fork.Close() // nolint: errcheck
+
+	fork.setting.stdin = r
+	fork.setting.stderr = w
+	fork.setting.exit = func(code int) {
+		// stop parsing the line after the help, but not exit the program
+		fork.setting.exited = true
+	}
+
+	switch _, err = fork.Set(args...); {
+	case fork.exited:
+		// stopped by the help, not the parse error
+		err = nil
+		return
+	case err != nil:
+		// cannot parse the line
+		return
+	}
+
+	if repl.handler != nil {
+		// run the parsed instance
+		err = repl.handler(fork.Value.Interface())
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package structopt
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ConsoleGet struct {
+	Help
+
+	Verbose bool `short:"v"`
+	Key     *string
+}
+
+type ConsoleDrop struct {
+	Force bool
+}
+
+type Console struct {
+	Help
+
+	Get  *ConsoleGet  `name:"get"`
+	Drop *ConsoleDrop `name:"drop"`
+}
+
+func TestREPL(t *testing.T) {
+	var logs []string
+
+	parser := MustNew(&Console{})
+	repl := parser.REPL(func(cmd interface{}) error {
+		console := cmd.(*Console)
+		switch {
+		case console.Get != nil:
+			logs = append(logs, fmt.Sprintf("get %v (verbose: %v)", *console.Get.Key, console.Get.Verbose))
+		case console.Drop != nil && !console.Drop.Force:
+			return fmt.Errorf("drop without --force")
+		case console.Drop != nil:
+			logs = append(logs, "drop")
+		}
+		return nil
+	})
+
+	input := strings.Join([]string{
+		"get -v 'the key'",
+		"get other",
+		"drop",
+		"!1",
+		"get --help",
+		"get 'unbalanced",
+		"history",
+		"g\t",
+		"exit",
+		"get ignored",
+	}, "\n")
+
+	output := &strings.Builder{}
+	if err := repl.Run(strings.NewReader(input), output); err != nil {
+		t.Fatalf("cannot run the REPL: %v", err)
+	}
+
+	expected := []string{"get the key (verbose: true)", "get other (verbose: false)", "get the key (verbose: true)"}
+	if !reflect.DeepEqual(logs, expected) {
+		t.Errorf("expect fresh instance per line: %#v", logs)
+	}
+
+	for _, msg := range []string{
+		"error: drop without --force",
+		"usage: get [OPTION] KEY",
+		"error: unbalanced quote",
+		"    4  get -v 'the key'",
+		"console> get\n",
+	} {
+		if !strings.Contains(output.String(), msg) {
+			t.Errorf("expect %#v in the output: %v", msg, output)
+		}
+	}
+
+	if history := repl.History(); len(history) != 7 || history[6] != "exit" {
+		t.Errorf("expect the history: %#v", history)
+	}
+
+	if candidates := repl.Complete("he"); !reflect.DeepEqual(candidates, []string{"help"}) {
+		t.Errorf("expect complete the built-in command: %v", candidates)
+	}
+
+	if candidates := repl.Complete("get -"); !reflect.DeepEqual(candidates, []string{"--help", "--verbose", "-h", "-v"}) {
+		t.Errorf("expect complete the sub-command options: %v", candidates)
+	}
+}
+
+func TestREPLConfirm(t *testing.T) {
+	var logs []string
+
+	parser := MustNew(&Warehouse{})
+	repl := parser.REPL(func(cmd interface{}) error {
+		warehouse := cmd.(*Warehouse)
+		logs = append(logs, fmt.Sprintf("purge: %v, drop: %v", warehouse.Purge, warehouse.Drop != nil))
+		return nil
+	})
+
+	input := strings.Join([]string{
+		"drop",
+		"y",
+		"--purge",
+		"n",
+		"exit",
+	}, "\n")
+
+	output := &strings.Builder{}
+	if err := repl.Run(strings.NewReader(input), output); err != nil {
+		t.Fatalf("cannot run the REPL: %v", err)
+	}
+
+	if !reflect.DeepEqual(logs, []string{"purge: false, drop: true"}) {
+		t.Errorf("expect confirm from the REPL input: %#v (%v)", logs, output)
+	}
+
+	for _, msg := range []string{"drop the warehouse? [y/N]: ", "error: --purge is not confirmed"} {
+		if !strings.Contains(output.String(), msg) {
+			t.Errorf("expect %#v in the output: %v", msg, output)
+		}
+	}
+}
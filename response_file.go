@@ -0,0 +1,80 @@
+package structopt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Expand the response file (@path) in the arguments into the arguments it contains, which are
+// split by the shell quoting rules with the comment and line continuation. The response file
+// may contain other response files, and the leading @@ is passed as the literal @. The stdin
+// (@-) of the BYTES option and the arguments after -- are passed unchanged.
+func ExpandResponseFiles(args []string) (expanded []string, err error) {
+	expanded, err = expand_response_files(args, nil)
+	return
+}
+
+// expand the response files recursively, the stack is the files being expanded
+func expand_response_files(args []string, stack []string) (expanded []string, err error) {
+	for idx, arg := range args {
+		switch {
+		case arg == "--":
+			// never expand the positional arguments
+			expanded = append(expanded, args[idx:]...)
+			return
+		case arg == "@-":
+			// the stdin of the BYTES option
+			expanded = append(expanded, arg)
+		case strings.HasPrefix(arg, "@@"):
+			// the escaped literal value
+			expanded = append(expanded, arg[1:])
+		case len(arg) > 1 && arg[0] == '@':
+			var words []string
+
+			if words, err = read_response_file(arg[1:], stack); err != nil {
+				// cannot read the response file
+				return
+			}
+
+			expanded = append(expanded, words...)
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return
+}
+
+// read the arguments from the response file, and expand the nested response files
+func read_response_file(path string, stack []string) (words []string, err error) {
+	var abs string
+	var data []byte
+
+	if abs, err = filepath.Abs(path); err != nil {
+		err = fmt.Errorf("invalid response file %#v: %v", path, err)
+		return
+	}
+
+	for _, included := range stack {
+		if included == abs {
+			err = fmt.Errorf("recursive response file: %v", strings.Join(append(stack, abs), " -> "))
+			return
+		}
+	}
+
+	log.Debug("read the response file: %v", abs)
+	if data, err = ioutil.ReadFile(path); err != nil {
+		err = fmt.Errorf("cannot read response file %#v: %v", path, err)
+		return
+	}
+
+	tokenizer := Tokenizer{Comments: true}
+	if words, err = tokenizer.Split(string(data)); err != nil {
+		err = fmt.Errorf("invalid response file %#v: %v", path, err)
+		return
+	}
+
+	words, err = expand_response_files(words, append(stack, abs))
+	return
+}
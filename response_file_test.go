@@ -0,0 +1,69 @@
+package structopt
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"args.txt":   "# the build options\n--name 'hello world' \\\n  --verbose\n@" + filepath.Join(dir, "nested.txt") + "\n",
+		"nested.txt": "\"nested arg\" @@literal\n",
+		"loop1.txt":  "a @" + filepath.Join(dir, "loop2.txt"),
+		"loop2.txt":  "b @" + filepath.Join(dir, "loop1.txt"),
+		"broken.txt": "--name 'unbalanced",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("cannot create %v: %v", name, err)
+		}
+	}
+
+	args := []string{"first", "@" + filepath.Join(dir, "args.txt"), "@", "@@last"}
+	expanded, err := ExpandResponseFiles(args)
+	if err != nil {
+		t.Fatalf("cannot expand %v: %v", args, err)
+	}
+
+	ans := []string{"first", "--name", "hello world", "--verbose", "nested arg", "@literal", "@", "@last"}
+	if !reflect.DeepEqual(expanded, ans) {
+		t.Errorf("expect expand %v: %#v", args, expanded)
+	}
+
+	cases := map[string]string{
+		"loop1.txt":     "recursive response file",
+		"broken.txt":    "unbalanced quote",
+		"not-exist.txt": "cannot read response file",
+	}
+	for name, msg := range cases {
+		if _, err := ExpandResponseFiles([]string{"@" + filepath.Join(dir, name)}); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expect cannot expand %v: %v", name, err)
+		}
+	}
+}
+
+func TestResponseFilesBytes(t *testing.T) {
+	args := []string{"--payload", "@-", "--", "@positional"}
+	if expanded, err := ExpandResponseFiles(args); err != nil || !reflect.DeepEqual(expanded, args) {
+		t.Errorf("expect pass %v unchanged: %#v (%v)", args, expanded, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "payload.txt")
+	if err := ioutil.WriteFile(path, []byte("payload from file"), 0600); err != nil {
+		t.Fatalf("cannot create %v: %v", path, err)
+	}
+
+	expanded, err := ExpandResponseFiles([]string{"--payload", "@@" + path})
+	if err != nil {
+		t.Fatalf("cannot expand @@%v: %v", path, err)
+	}
+
+	cipher := Cipher{}
+	if _, err := MustNew(&cipher).Set(expanded...); err != nil || string(cipher.Payload) != "payload from file" {
+		t.Errorf("expect --payload @@%v read the file: %q (%v)", path, cipher.Payload, err)
+	}
+}
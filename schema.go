@@ -0,0 +1,624 @@
+package structopt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// the analyzed struct types, keyed by the reflect.Type and shared by all the parsers
+var schemas sync.Map
+
+// The immutable analysis of the struct type, which is independent of the instance and safe
+// for concurrent use. The instance is bound to the schema in new_struct_opt.
+type struct_schema struct {
+	// the default name of the command, the lower-case name of the struct
+	name string
+	// the options and sub-commands, the ignored and skipped fields are excluded
+	fields []*field_schema
+	// any option should be confirmed, the --yes option is generated
+	confirm bool
+}
+
+// the analyzed field which is the option or the sub-command
+type field_schema struct {
+	reflect.StructField
+
+	// the index sequence of the field, may nested in the embedded struct
+	index []int
+	// the name of the option or the sub-command
+	name string
+	// the option type, or Subcommand
+	kind Type
+	// the flip, flag or argument, nil if the sub-command
+	flag *flag_schema
+	// the --x-file companion option, may nil
+	companion *flag_schema
+}
+
+// the static properties of the flip, flag or argument shared by the FlipFlag
+type flag_schema struct {
+	// The field of the option in the struct
+	reflect.StructTag
+
+	// Name of the command-line, default is the name of field.
+	name string
+	// The pre-defined value can used.
+	choices []string
+	// The unit of the INT/UINT value
+	unit string
+	// The accepted layouts and the zone of the TIME value
+	layouts  []string
+	location *time.Location
+	// The os.OpenFile flag and permission of the FILE
+	file_flag int
+	file_perm os.FileMode
+	// The rules of the PATH
+	path_rules map[string]struct{}
+
+	// The maximal size of the IPSET
+	limit uint64
+	// The length range of the BYTES
+	min_len uint64
+	max_len uint64
+
+	// The value is redacted in help, log and error
+	secret bool
+	// The option is repeatable, the field is the slice
+	multiple bool
+
+	// option is required
+	required         bool
+	option_type      Type
+	option_type_hint TypeHint
+}
+
+// the cached schema of the struct type, analyze and cache if not found
+func schema_of(typ reflect.Type) (schema *struct_schema, err error) {
+	if cached, ok := schemas.Load(typ); ok {
+		// analyzed before
+		schema = cached.(*struct_schema)
+		return
+	}
+
+	if schema, err = analyze_struct(typ); err != nil {
+		// never cache the failure
+		return
+	}
+
+	// the concurrent analysis may store first, always use the stored one
+	cached, _ := schemas.LoadOrStore(typ, schema)
+	schema = cached.(*struct_schema)
+	return
+}
+
+// analyze the fields of the struct, the embedded struct is expanded as the fields
+func analyze_struct(typ reflect.Type) (schema *struct_schema, err error) {
+	schema = &struct_schema{
+		name: strings.ToLower(typ.Name()),
+	}
+
+	log.Trace("analyze the struct: %v", typ)
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		log.Trace("process #%d field: %v (%v/%v)", idx, field.Name, field.Type, field.Type.Kind())
+
+		switch {
+		case field.PkgPath != "":
+			// field cannot set, skip
+		case field.Type.Kind() == reflect.Struct && field.Anonymous:
+			for sub_idx := 0; sub_idx < field.Type.NumField(); sub_idx++ {
+				sub_field := field.Type.Field(sub_idx)
+				log.Trace("process #%d sub-field in %v: %v (%v)", sub_idx, field.Type, sub_field.Name, sub_field.Type)
+
+				if sub_field.PkgPath != "" {
+					// field cannot set, skip
+					continue
+				}
+
+				if err = schema.add_field(sub_field, []int{idx, sub_idx}); err != nil {
+					err = fmt.Errorf("cannot set %v as option: %v", sub_field.Name, err)
+					schema = nil
+					return
+				}
+			}
+		default:
+			if err = schema.add_field(field, []int{idx}); err != nil {
+				err = fmt.Errorf("cannot set %v as option: %v", field.Name, err)
+				schema = nil
+				return
+			}
+		}
+	}
+	return
+}
+
+// analyze the field and add to the schema, the ignored and skipped fields are dropped
+func (schema *struct_schema) add_field(field reflect.StructField, index []int) (err error) {
+	var analyzed *field_schema
+
+	if analyzed, err = analyze_field(field, index); err != nil {
+		log.Warn("cannot set %v as option: %v", field.Name, err)
+		return
+	}
+
+	if analyzed != nil {
+		schema.fields = append(schema.fields, analyzed)
+		schema.confirm = schema.confirm || (analyzed.kind != Subcommand && field.Tag.Get(TAG_CONFIRM) != "")
+	}
+	return
+}
+
+// analyze the field as the option or the sub-command, nil if ignored or skipped
+func analyze_field(field reflect.StructField, index []int) (schema *field_schema, err error) {
+	tags := option_tags(field)
+	_, skip := tags[TAG_SKIP]
+	_, required := tags[TAG_REQUIRED]
+	_, flag := tags[TAG_FLAG]
+	_, is_json := tags[TAG_JSON]
+
+	log.Debug("process %v (%v) as option (skip: %v, kind: %v)", field.Name, field.Type, skip, field.Type.Kind())
+	switch {
+	case TAG_IGNORE == strings.TrimSpace(string(field.Tag)):
+		log.Debug("option %v set ignore", field.Name)
+		return
+	case skip:
+		log.Debug("option %v set skip", field.Name)
+		return
+	}
+
+	schema = &field_schema{
+		StructField: field,
+		index:       index,
+	}
+
+	switch is_ptr := field.Type.Kind() == reflect.Ptr; {
+	case is_ptr && !flag && field.Type.Elem().Kind() == reflect.Struct && !is_value_type(field.Type.Elem()) && !is_json:
+		// the sub-command, the struct is analyzed when bound
+		schema.kind = Subcommand
+		schema.name = strings.ToLower(field.Type.Elem().Name())
+		if name := field.Tag.Get(TAG_NAME); name != "" {
+			// override the name
+			schema.name = name
+		}
+	default:
+		if schema.flag, err = analyze_flag(field); err != nil {
+			if is_ptr {
+				// may sub-command or argument
+				err = fmt.Errorf("cannot create option %v: %v", field.Name, err)
+			}
+			return
+		}
+
+		if is_ptr && !flag {
+			// the pointer is the argument, unless force set as flag
+			schema.flag.option_type = Argument
+		}
+		schema.flag.required = required
+		schema.kind = schema.flag.option_type
+		schema.name = schema.flag.name
+	}
+
+	if _, ok := tags[TAG_FILE]; ok {
+		// add the --x-file companion option
+		if schema.companion, err = analyze_companion(schema); err != nil {
+			err = fmt.Errorf("cannot set option %v: %v", schema.name, err)
+			return
+		}
+	}
+	return
+}
+
+// analyze the flip, flag or argument by the field type and tags
+func analyze_flag(field reflect.StructField) (option *flag_schema, err error) {
+	typ := field.Type
+	log.Trace("try analyze option from %v (%v)", field.Name, typ)
+
+	elm := reflect.New(typ).Elem()
+	for elm.Kind() == reflect.Ptr {
+		elm = reflect.New(elm.Type().Elem()).Elem()
+	}
+
+	option = &flag_schema{
+		StructTag: field.Tag,
+
+		name: strings.ToLower(field.Name),
+	}
+	if name := field.Tag.Get(TAG_NAME); name != "" {
+		// override the option name
+		option.name = name
+	}
+
+	tags := option_tags(field)
+	_, is_json := tags[TAG_JSON]
+	_, secret := tags[TAG_SECRET]
+
+	if elm.Kind() == reflect.Slice && elm.Type().Elem().Kind() != reflect.Uint8 && !is_json {
+		// the repeatable option, process as the element
+		option.multiple = true
+
+		typ = elm.Type().Elem()
+		for elm = reflect.New(typ).Elem(); elm.Kind() == reflect.Ptr; {
+			elm = reflect.New(elm.Type().Elem()).Elem()
+		}
+	}
+	if val := option.StructTag.Get(TAG_CHOICE); val != "" {
+		choices := strings.Split(val, " ")
+		sort.Strings(choices)
+		option.choices = choices
+	}
+
+	log.Debug("try analyze option %v: %T (kind: %v)", option.name, elm.Interface(), elm.Kind())
+	switch elm.Interface().(type) {
+	case os.File:
+		// the flag / os.File
+		option.option_type = Flag
+		option.option_type_hint = FILE
+	case os.FileMode:
+		// the flag / os.FileMode
+		option.option_type = Flag
+		option.option_type_hint = FMODE
+	case time.Time:
+		// the flag / os.File
+		option.option_type = Flag
+		option.option_type_hint = TIME
+	case time.Duration:
+		// the flag / os.File
+		option.option_type = Flag
+		option.option_type_hint = SPAN
+	case time.Location:
+		// the flag / time.Location
+		option.option_type = Flag
+		option.option_type_hint = ZONE
+	case time.Month:
+		// the flag / time.Month
+		option.option_type = Flag
+		option.option_type_hint = MONTH
+	case time.Weekday:
+		// the flag / time.Weekday
+		option.option_type = Flag
+		option.option_type_hint = WEEKDAY
+	case net.Interface:
+		// the flag / net.Interface
+		option.option_type = Flag
+		option.option_type_hint = IFACE
+	case net.IP:
+		// the flag / net.IP
+		option.option_type = Flag
+		option.option_type_hint = IP
+	case net.IPNet, netip.Prefix:
+		// the flag / net.IPNet
+		option.option_type = Flag
+		option.option_type_hint = CIDR
+	case netip.Addr:
+		// the flag / netip.Addr
+		option.option_type = Flag
+		option.option_type_hint = IP
+	case netip.AddrPort, net.TCPAddr, net.UDPAddr:
+		// the flag / netip.AddrPort, net.TCPAddr or net.UDPAddr
+		option.option_type = Flag
+		option.option_type_hint = ADDR
+	case url.URL:
+		// the flag / url.URL
+		option.option_type = Flag
+		option.option_type_hint = URL
+	case net.HardwareAddr:
+		// the flag / net.HardwareAddr
+		option.option_type = Flag
+		option.option_type_hint = MAC
+	case Port, PortRange:
+		// the flag / port or port range
+		option.option_type = Flag
+		option.option_type_hint = PORT
+	case IPSet:
+		// the flag / the set of IP address
+		option.option_type = Flag
+		option.option_type_hint = IPSET
+	case big.Int:
+		// the flag / arbitrary-precision integer
+		option.option_type = Flag
+		option.option_type_hint = INT
+	case big.Rat, big.Float:
+		// the flag / exact rational or arbitrary-precision float
+		option.option_type = Flag
+		option.option_type_hint = RAT
+	case regexp.Regexp:
+		// the flag / regular expression
+		option.option_type = Flag
+		option.option_type_hint = REGEXP
+	case template.Template:
+		if typ.Kind() != reflect.Ptr {
+			// the copied template still shares the associated templates with the original one
+			err = fmt.Errorf("TMPL only used in *template.Template: %v (%v)", field.Name, typ)
+			return
+		}
+
+		// the flag / text template
+		option.option_type = Flag
+		option.option_type_hint = TMPL
+	case json.RawMessage:
+		// the flag / raw JSON value
+		option.option_type = Flag
+		option.option_type_hint = JSON
+	default:
+		switch elm.Kind() {
+		case reflect.Bool:
+			option.option_type = Flip
+			option.option_type_hint = NONE
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			option.option_type = Flag
+			option.option_type_hint = INT
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			option.option_type = Flag
+			option.option_type_hint = UINT
+		case reflect.Float32, reflect.Float64:
+			// the flag / sign-rational number
+			option.option_type = Flag
+			option.option_type_hint = RAT
+		case reflect.String:
+			option.option_type = Flag
+			option.option_type_hint = STR
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			switch kind := elm.Kind(); {
+			case is_json:
+				// decoded as JSON
+			case (kind == reflect.Slice || kind == reflect.Array) && elm.Type().Elem().Kind() == reflect.Uint8:
+				// the flag / []byte or [N]byte
+				option.option_type = Flag
+				option.option_type_hint = BYTES
+			default:
+				log.Warn("not implemented: %v (type: %v, kind: %v) as flag", field.Name, typ, elm.Kind())
+				err = fmt.Errorf("not implemented: %v (%v)", typ, elm.Kind())
+				return
+			}
+		case reflect.Interface:
+			if typ == reflect.TypeOf((*fs.FS)(nil)).Elem() {
+				// the flag / fs.FS which is the existing directory
+				option.option_type = Flag
+				option.option_type_hint = PATH
+				option.path_rules = map[string]struct{}{PATH_EXISTS: {}, PATH_DIR: {}}
+				break
+			}
+
+			if typ.NumMethod() == 0 || !reflect.TypeOf(&LazyFile{}).Implements(typ) {
+				log.Warn("not implemented: %v (type: %v) as file", field.Name, typ)
+				err = fmt.Errorf("not implemented: %v (%v)", typ, elm.Kind())
+				return
+			}
+
+			// the flag / io.Reader, io.Writer ... which lazy-open the file
+			option.option_type = Flag
+			option.option_type_hint = FILE
+		default:
+			log.Warn("not implemented: %v (type: %v, kind: %v) as flag", field.Name, typ, elm.Kind())
+			err = fmt.Errorf("not implemented: %v (%v)", typ, elm.Kind())
+			return
+		}
+	}
+
+	if is_json {
+		// decode the value as JSON
+		option.option_type = Flag
+		option.option_type_hint = JSON
+	}
+
+	if unit := field.Tag.Get(TAG_UNIT); unit != "" {
+		switch {
+		case elm.Kind() == reflect.Struct, option.option_type_hint != INT && option.option_type_hint != UINT:
+			err = fmt.Errorf("unit only used in INT/UINT: %v (%v)", field.Name, option.option_type_hint)
+			return
+		case unit != UNIT_BYTES && unit != UNIT_SI:
+			err = fmt.Errorf("unknown unit %v: %v", field.Name, unit)
+			return
+		}
+		option.unit = unit
+	}
+
+	if layout := field.Tag.Get(TAG_LAYOUT); layout != "" {
+		if option.option_type_hint != TIME {
+			err = fmt.Errorf("layout only used in TIME: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+		option.layouts = strings.Split(layout, TAG_LAYOUT_SEP)
+	}
+
+	if tz := field.Tag.Get(TAG_TZ); tz != "" {
+		if option.option_type_hint != TIME {
+			err = fmt.Errorf("tz only used in TIME: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+
+		if option.location, err = AtoLocation(tz); err != nil {
+			err = fmt.Errorf("invalid %v tz %v: %v", field.Name, tz, err)
+			return
+		}
+	}
+
+	if rules, ok := field.Tag.Lookup(TAG_PATH); ok {
+		if option.option_type_hint != STR && option.option_type_hint != PATH {
+			err = fmt.Errorf("path only used in STR or fs.FS: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+
+		option.option_type_hint = PATH
+		if option.path_rules == nil {
+			option.path_rules = map[string]struct{}{}
+		}
+
+		for _, rule := range strings.Fields(rules) {
+			switch rule {
+			case PATH_EXISTS, PATH_DIR, PATH_FILE, PATH_CREATABLE, PATH_ABS, PATH_ENV:
+				option.path_rules[rule] = struct{}{}
+			default:
+				err = fmt.Errorf("unknown %v path rule: %v", field.Name, rule)
+				return
+			}
+		}
+	}
+
+	if family, ok := field.Tag.Lookup(TAG_RESOLVE); ok {
+		switch {
+		case option.option_type_hint != IP && option.option_type_hint != ADDR:
+			err = fmt.Errorf("resolve only used in IP or ADDR: %v (%v)", field.Name, option.option_type_hint)
+			return
+		case family != "" && family != RESOLVE_IP && family != RESOLVE_IP4 && family != RESOLVE_IP6:
+			err = fmt.Errorf("unknown %v resolve family: %v", field.Name, family)
+			return
+		}
+	}
+
+	if family, ok := field.Tag.Lookup(TAG_FAMILY); ok {
+		switch option.option_type_hint {
+		case IP, CIDR, ADDR, IPSET:
+		default:
+			err = fmt.Errorf("family only used in IP, CIDR, ADDR or IPSET: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+
+		if family != RESOLVE_IP4 && family != RESOLVE_IP6 {
+			err = fmt.Errorf("unknown %v family: %v", field.Name, family)
+			return
+		}
+	}
+
+	if field.Tag.Get(TAG_SCHEME) != "" && option.option_type_hint != URL {
+		err = fmt.Errorf("scheme only used in URL: %v (%v)", field.Name, option.option_type_hint)
+		return
+	}
+
+	if limit := field.Tag.Get(TAG_LIMIT); limit != "" {
+		if option.option_type_hint != IPSET {
+			err = fmt.Errorf("limit only used in IPSET: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+
+		if option.limit, err = AtoU(limit); err != nil {
+			err = fmt.Errorf("invalid %v limit: %v", field.Name, limit)
+			return
+		}
+	}
+
+	if encoding := field.Tag.Get(TAG_ENCODING); encoding != "" {
+		switch {
+		case option.option_type_hint != BYTES:
+			err = fmt.Errorf("encoding only used in BYTES: %v (%v)", field.Name, option.option_type_hint)
+			return
+		case encoding != ENCODING_RAW && encoding != ENCODING_HEX && encoding != ENCODING_BASE64 && encoding != ENCODING_BASE64URL:
+			err = fmt.Errorf("unknown %v encoding: %v", field.Name, encoding)
+			return
+		}
+	}
+
+	if length := field.Tag.Get(TAG_LEN); length != "" {
+		if option.option_type_hint != BYTES {
+			err = fmt.Errorf("len only used in BYTES: %v (%v)", field.Name, option.option_type_hint)
+			return
+		}
+
+		if option.min_len, option.max_len, err = AtoLength(length); err != nil {
+			err = fmt.Errorf("invalid %v len: %v", field.Name, err)
+			return
+		}
+	}
+
+	if mode, ok := field.Tag.Lookup(TAG_CIDR); ok {
+		switch {
+		case option.option_type_hint != CIDR:
+			err = fmt.Errorf("cidr only used in CIDR: %v (%v)", field.Name, option.option_type_hint)
+			return
+		case mode != CIDR_HOST && mode != CIDR_NETWORK:
+			err = fmt.Errorf("unknown %v cidr mode: %v", field.Name, mode)
+			return
+		}
+	}
+
+	if mode, ok := field.Tag.Lookup(TAG_GLOB); ok {
+		switch {
+		case option.option_type_hint != FILE && option.option_type_hint != PATH:
+			err = fmt.Errorf("glob only used in FILE or PATH: %v (%v)", field.Name, option.option_type_hint)
+			return
+		case mode != "" && mode != GLOB_STRICT && mode != GLOB_LITERAL:
+			err = fmt.Errorf("unknown %v glob mode: %v", field.Name, mode)
+			return
+		}
+	}
+
+	if secret && option.option_type == Flip {
+		err = fmt.Errorf("secret only used in flag or argument: %v (%v)", field.Name, typ)
+		return
+	}
+
+	if option.multiple && option.option_type == Flip {
+		err = fmt.Errorf("not implemented: %v (%v) as repeatable flip", field.Name, typ)
+		return
+	}
+
+	if option.option_type_hint == FILE {
+		if option.file_flag, option.file_perm, err = file_mode_of(field); err != nil {
+			err = fmt.Errorf("invalid %v file mode: %v", field.Name, err)
+			return
+		}
+	}
+
+	option.secret = secret
+	return
+}
+
+// the os.OpenFile flag and permission of the FILE by TAG_MODE and TAG_PERM
+func file_mode_of(field reflect.StructField) (flag int, perm os.FileMode, err error) {
+	mode, ok := field.Tag.Lookup(TAG_MODE)
+	if !ok {
+		// the write-only interface, like io.Writer, create and truncate the file by default
+		typ := field.Type
+		if typ.Kind() == reflect.Interface && typ.Implements(reflect.TypeOf((*io.Writer)(nil)).Elem()) &&
+			!typ.Implements(reflect.TypeOf((*io.Reader)(nil)).Elem()) {
+			mode = strings.Join([]string{FILE_WRITE, FILE_CREATE, FILE_TRUNCATE}, " ")
+		}
+	}
+
+	if flag, err = AtoFileFlag(mode); err != nil {
+		return
+	}
+
+	perm = 0666
+	if val := field.Tag.Get(TAG_PERM); val != "" {
+		if perm, err = AtoFileMode(val); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// the struct type which is the value of the option, not the sub-command
+func is_value_type(typ reflect.Type) (ok bool) {
+	switch reflect.New(typ).Elem().Interface().(type) {
+	case os.File, time.Time, time.Location, net.Interface, net.IPNet, netip.Addr, netip.Prefix, netip.AddrPort,
+		net.TCPAddr, net.UDPAddr, url.URL, PortRange, IPSet, big.Int, big.Rat, big.Float, regexp.Regexp,
+		template.Template:
+		ok = true
+	}
+	return
+}
+
+// the special tags in TAG_OPTION, like skip or flag
+func option_tags(field reflect.StructField) (tags map[string]struct{}) {
+	tags = map[string]struct{}{}
+	for _, tag := range strings.Split(field.Tag.Get(TAG_OPTION), TAG_OPTION_SEP) {
+		// tag = strings.TrimSpace(tag)
+		tags[tag] = struct{}{}
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package structopt
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSchemaCache(t *testing.T) {
+	typ := reflect.TypeOf(Bench{})
+
+	schema, err := schema_of(typ)
+	if err != nil {
+		t.Fatalf("cannot analyze %v: %v", typ, err)
+	}
+
+	if cached, _ := schema_of(typ); cached != schema {
+		t.Errorf("expect the cached schema of %v", typ)
+	}
+
+	type Invalid struct {
+		Ch chan int
+	}
+
+	if _, err := schema_of(reflect.TypeOf(Invalid{})); err == nil {
+		t.Errorf("expect cannot analyze the chan")
+	} else if _, ok := schemas.Load(reflect.TypeOf(Invalid{})); ok {
+		t.Errorf("expect never cache the failure")
+	}
+}
+
+func TestConcurrentNew(t *testing.T) {
+	var wg sync.WaitGroup
+
+	benches := make([]Bench, 16)
+	errs := make([]error, len(benches))
+	for idx := range benches {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			parser, err := New(&benches[idx])
+			if err == nil {
+				_, err = parser.Set("-n", fmt.Sprintf("bench-%d", idx), "--tags", "c", "out", "benchsub")
+			}
+			errs[idx] = err
+		}(idx)
+	}
+	wg.Wait()
+
+	for idx, bench := range benches {
+		switch {
+		case errs[idx] != nil:
+			t.Errorf("cannot parse #%d: %v", idx, errs[idx])
+		case bench.Name != fmt.Sprintf("bench-%d", idx) || bench.Size != 64*1024:
+			t.Errorf("expect #%d set --name and the default --size: %v %v", idx, bench.Name, bench.Size)
+		case !reflect.DeepEqual(bench.Tags, []string{"c"}):
+			t.Errorf("expect #%d replace the default --tags: %v", idx, bench.Tags)
+		case bench.Sub == nil || bench.Sub.Level != "info":
+			t.Errorf("expect #%d set the sub-command: %v", idx, bench.Sub)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package structopt
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// read the value of the secret option from the source: the environment variable (env:NAME),
+// the file (file:PATH) or the no-echo prompt (prompt), or warn the value passed on argv.
+func (option *FlipFlag) read_secret(arg string) (value string, err error) {
+	switch {
+	case strings.HasPrefix(arg, SECRET_ENV):
+		name := strings.TrimPrefix(arg, SECRET_ENV)
+
+		var ok bool
+		if value, ok = os.LookupEnv(name); !ok {
+			err = fmt.Errorf("%v: environment variable %v not set", option.Name(), name)
+			return
+		}
+	case strings.HasPrefix(arg, SECRET_FILE):
+		var data []byte
+
+		path := strings.TrimPrefix(arg, SECRET_FILE)
+		if data, err = ioutil.ReadFile(path); err != nil {
+			err = fmt.Errorf("%v: cannot read %#v: %v", option.Name(), path, err)
+			return
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	case arg == SECRET_PROMPT:
+		value, err = option.prompt_secret()
+	default:
+		fmt.Fprintf(option.stderr, "warning: %v passed on the command line may be visible to other users, "+
+			"use %vNAME, %vPATH or %v instead\n", option.Name(), SECRET_ENV, SECRET_FILE, SECRET_PROMPT)
+		value = arg
+	}
+	return
+}
+
+// ask the secret value without echo when the stdin is the terminal
+func (option *FlipFlag) prompt_secret() (value string, err error) {
+	fmt.Fprintf(option.stderr, "%v: ", option.Name())
+
+	switch file, ok := option.stdin.(*os.File); {
+	case ok && is_terminal(file):
+		value, err = read_password(file)
+		// the newline is not echoed
+		fmt.Fprintln(option.stderr)
+	default:
+		value, err = read_line(option.stdin)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("%v: cannot read the prompt: %v", option.Name(), err)
+		return
+	}
+	return
+}
+
+// the option is secret, which should never be shown
+func is_secret(option Option) (secret bool) {
+	if flip, ok := option.(*FlipFlag); ok {
+		// only FlipFlag may be secret
+		secret = flip.secret
+	}
+	return
+}
+
+// read the line without buffering, so the remains input can be read later
+func read_line(r io.Reader) (line string, err error) {
+	var buff []byte
+
+	ch := make([]byte, 1)
+	for {
+		var n int
+
+		n, err = r.Read(ch)
+		switch {
+		case n > 0 && ch[0] == '\n':
+			line = strings.TrimRight(string(buff), "\r")
+			err = nil
+			return
+		case n > 0:
+			buff = append(buff, ch[0])
+		}
+
+		if err == io.EOF && len(buff) > 0 {
+			// the last line without newline
+			line = strings.TrimRight(string(buff), "\r")
+			err = nil
+			return
+		} else if err != nil {
+			return
+		}
+	}
+}
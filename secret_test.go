@@ -0,0 +1,123 @@
+package structopt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type Login struct {
+	Password string  `short:"p" option:"secret" default:"hunter2"`
+	Pin      int     `option:"secret"`
+	Token    *string `option:"secret"`
+}
+
+func TestSecretOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(path, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("cannot create %v: %v", path, err)
+	}
+	t.Setenv("STRUCTOPT_PASSWORD", "env-password")
+
+	login := Login{}
+	parser := MustNew(&login)
+	stderr := &strings.Builder{}
+	parser.SetStderr(stderr)
+	parser.SetStdin(strings.NewReader("1234\n"))
+
+	if usage := parser.Usage(); strings.Contains(usage, "hunter2") || !strings.Contains(usage, "(default: "+SECRET_MASK+")") {
+		t.Errorf("expect mask the secret default: %v", usage)
+	}
+
+	args := []string{"-p", SECRET_ENV + "STRUCTOPT_PASSWORD", "--pin", SECRET_PROMPT, SECRET_FILE + path}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case login.Password != "env-password":
+		t.Errorf("expect --password from env: %v", login.Password)
+	case login.Pin != 1234:
+		t.Errorf("expect --pin from prompt: %v", login.Pin)
+	case login.Token == nil || *login.Token != "file-token":
+		t.Errorf("expect TOKEN from file: %v", login.Token)
+	case stderr.String() != "pin: ":
+		t.Errorf("expect prompt without warning: %#v", stderr.String())
+	}
+
+	if _, err := parser.Set("-p", "plain-password"); err != nil || login.Password != "plain-password" {
+		t.Errorf("expect set --password on argv: %v (%v)", login.Password, err)
+	} else if !strings.Contains(stderr.String(), "warning: password") {
+		t.Errorf("expect warn the secret on argv: %v", stderr)
+	}
+
+	if _, err := parser.Set("--pin", "x9876"); err == nil || strings.Contains(err.Error(), "9876") {
+		t.Errorf("expect redact the secret in error: %v", err)
+	}
+
+	if _, err := parser.Set("--pin", "p"); err == nil || err.Error() != "set --pin: invalid INT value "+SECRET_MASK {
+		t.Errorf("expect never show the short secret in error: %v", err)
+	}
+
+	cases := [][]string{
+		{"-p", SECRET_ENV + "STRUCTOPT_NOT_EXISTS"},
+		{"-p", SECRET_FILE + filepath.Join(t.TempDir(), "not-exists")},
+		{"--pin", SECRET_PROMPT},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
+
+// record the formatted log in test
+type log_recorder struct {
+	strings.Builder
+}
+
+func (recorder *log_recorder) Trace(format string, args ...interface{}) {
+	fmt.Fprintf(recorder, format+"\n", args...)
+}
+
+func (recorder *log_recorder) Debug(format string, args ...interface{}) {
+	fmt.Fprintf(recorder, format+"\n", args...)
+}
+
+func (recorder *log_recorder) Info(format string, args ...interface{}) {
+	fmt.Fprintf(recorder, format+"\n", args...)
+}
+
+func (recorder *log_recorder) Warn(format string, args ...interface{}) {
+	fmt.Fprintf(recorder, format+"\n", args...)
+}
+
+func TestSecretLog(t *testing.T) {
+	recorder := &log_recorder{}
+	origin := log
+	log = recorder
+	defer func() { log = origin }()
+
+	// the secret loaded before New, like from the config
+	login := Login{Password: "loaded-password"}
+	parser := MustNew(&login)
+	parser.SetStderr(ioutil.Discard)
+
+	args := []string{"--pin", "1234", "argv-token"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	for _, secret := range []string{"loaded-password", "1234", "argv-token"} {
+		if strings.Contains(recorder.String(), secret) {
+			t.Errorf("expect never log the secret %v: %v", secret, recorder)
+		}
+	}
+
+	if !strings.Contains(recorder.String(), "try create option from password ("+SECRET_MASK+")") {
+		t.Errorf("expect log the masked secret: %v", recorder)
+	}
+}
@@ -2,11 +2,11 @@ package structopt
 
 import (
 	"fmt"
-	"net"
+	"io"
 	"os"
 	"reflect"
-	"sort"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -14,8 +14,13 @@ import (
 type StructOpt struct {
 	// The raw value of the input struct, should be the pointer of the value.
 	reflect.Value
-	// the reference instance of the parent StructOpt
-	ref reflect.Value
+	// the reference instance of the parent StructOpt, and its value before parsing
+	ref         reflect.Value
+	ref_initial reflect.Value
+	// the copy of the input struct before parsing, used in Fork
+	initial reflect.Value
+	// the runtime setting shared with the sub-commands and options
+	*setting
 
 	// callback function when set
 	Callback
@@ -24,6 +29,10 @@ type StructOpt struct {
 	name string
 	// The help message
 	help string
+	// The confirmation message of the sub-command, may empty
+	confirm string
+	// The generated --yes option which bypass the confirmation, may nil
+	yes_option *FlipFlag
 	// The properties of the Option used in StructOpt.
 	named_options map[string]Option
 
@@ -32,6 +41,38 @@ type StructOpt struct {
 	sub_options []Option
 }
 
+// The runtime setting shared by the StructOpt, its sub-commands and options.
+type setting struct {
+	// the clock used to calculate the relative time
+	now func() time.Time
+	// the opened files which should be closed by StructOpt.Close
+	closers []io.Closer
+	// the number of the closers opened by the defaults, kept by StructOpt.Reset
+	defaults int
+	// the hostname resolver used in the IP option
+	resolver Resolver
+	// the network interface provider used in the IFACE option
+	ifaces InterfaceProvider
+	// the functions used in the TMPL option
+	funcs template.FuncMap
+	// the standard input used in the BYTES option (like @-) and the prompt
+	stdin io.Reader
+	// the standard error used in the warning, prompt and usage
+	stderr io.Writer
+	// exit the program after the help or the parse error, replaced in the REPL
+	exit func(code int)
+	// the replaced exit is called, stop parsing the remaining arguments
+	exited bool
+	// expand the response files (@path) in Run
+	response_files bool
+	// ask the missing required options, see SetPrompt
+	prompt bool
+	// bypass the confirmation, set by the --yes option
+	yes bool
+	// the width to wrap the usage, detected if zero
+	width int
+}
+
 // Must generate the parse, or raise panic when failure.
 func MustNew(in interface{}) (opt *StructOpt) {
 	var err error
@@ -46,6 +87,32 @@ func MustNew(in interface{}) (opt *StructOpt) {
 
 // Generate the parse by input struct, or return error message.
 func New(in interface{}) (opt *StructOpt, err error) {
+	opt, err = NewWithFuncs(in, nil)
+	return
+}
+
+// Generate the parse with the functions used in the TMPL option, which are also available in
+// the default of the TMPL option, or return error message.
+func NewWithFuncs(in interface{}, funcs template.FuncMap) (opt *StructOpt, err error) {
+	setting := &setting{now: time.Now, resolver: net_resolver{}, ifaces: net_interfaces{}, stdin: os.Stdin, stderr: os.Stderr, exit: os.Exit}
+	if len(funcs) > 0 {
+		setting.funcs = template.FuncMap{}
+		for name, fn := range funcs {
+			setting.funcs[name] = fn
+		}
+	}
+
+	if opt, err = new_struct_opt(in, setting); err != nil {
+		return
+	}
+
+	opt.setting.defaults = len(opt.setting.closers)
+	return
+}
+
+func new_struct_opt(in interface{}, setting *setting) (opt *StructOpt, err error) {
+	var schema *struct_schema
+
 	value := reflect.ValueOf(in)
 
 	log.Trace("StructOpt.New(%T)", in)
@@ -61,135 +128,80 @@ func New(in interface{}) (opt *StructOpt, err error) {
 		return
 	}
 
+	if schema, err = schema_of(value.Elem().Type()); err != nil {
+		// cannot analyze the struct
+		return
+	}
+
 	opt = &StructOpt{
-		Value: value,
+		Value:   value,
+		setting: setting,
+		initial: snapshot_struct(value.Elem()),
 
-		name:          strings.ToLower(value.Elem().Type().Name()),
+		name:          schema.name,
 		named_options: map[string]Option{},
 	}
 
-	// generate the options
+	// bind the options to the instance
 	based := opt.Value.Elem()
-	for idx := 0; idx < based.NumField(); idx++ {
-		field := based.Type().Field(idx)
-		value := opt.Value.Elem().Field(idx)
-		log.Trace("process #%d field: %v (%v/%v)", idx, field.Name, field.Type, field.Type.Kind())
+	for _, field := range schema.fields {
+		if err = opt.new_option(based, field); err != nil {
+			log.Warn("cannot set %v as option: %v", field.Name, err)
+			err = fmt.Errorf("cannot set %v as option: %v", field.Name, err)
+			return
+		}
+	}
 
-		switch {
-		case !value.CanSet():
-			// field cannot set, skip
-		case field.Type.Kind() == reflect.Struct && field.Anonymous:
-			for sub_idx := 0; sub_idx < field.Type.NumField(); sub_idx++ {
-				sub_field := field.Type.Field(sub_idx)
-				sub_value := value.Field(sub_idx)
-				log.Trace("process #%d sub-field in %v: %v (%v)", sub_idx, field.Type, sub_field.Name, sub_field.Type)
-
-				// add option
-				if err = opt.new_option(based, sub_value, sub_field); err != nil {
-					log.Warn("cannot set %v as option: %v", sub_field.Name, err)
-					err = fmt.Errorf("cannot set %v as option: %v", sub_field.Name, err)
-					return
-				}
-			}
-		default:
-			// add option
-			if err = opt.new_option(based, value, field); err != nil {
-				log.Warn("cannot set %v as option: %v", field.Name, err)
-				err = fmt.Errorf("cannot set %v as option: %v", field.Name, err)
-				return
-			}
+	if schema.confirm {
+		// the confirmed option can be bypassed by the --yes option
+		if err = opt.new_yes_option(); err != nil {
+			err = fmt.Errorf("cannot set %v as option: %v", CONFIRM_YES, err)
+			return
 		}
 	}
 	return
 }
 
-func (opt *StructOpt) new_option(based reflect.Value, value reflect.Value, field reflect.StructField) (err error) {
+// bind the analyzed field of the instance as the option or the sub-command
+func (opt *StructOpt) new_option(based reflect.Value, field *field_schema) (err error) {
 	var option Option
 
-	tags := map[string]struct{}{}
-	for _, tag := range strings.Split(field.Tag.Get(TAG_OPTION), TAG_OPTION_SEP) {
-		// tag = strings.TrimSpace(tag)
-		tags[tag] = struct{}{}
-	}
+	value := based.FieldByIndex(field.index)
+	switch field.kind {
+	case Subcommand:
+		ref := value
+		if value.IsZero() {
+			// create dummy instance, and not set back
+			value = reflect.New(field.Type.Elem())
+			log.Trace("create dummy instance from %v: %v", field.Type.Elem(), value)
+		}
 
-	_, skip := tags[TAG_SKIP]
-	_, required := tags[TAG_REQUIRED]
+		var sub *StructOpt
+		if sub, err = new_struct_opt(value.Interface(), opt.setting); err != nil {
+			log.Warn("create sub-command from %v: %v", field.Type.Elem(), err)
+			err = fmt.Errorf("create sub-command from %v: %v", field.Type.Elem(), err)
+			return
+		}
 
-	log.Debug("process %v (%v) as option (skip: %v, kind: %v)", field.Name, field.Type, skip, field.Type.Kind())
-	switch {
-	case TAG_IGNORE == strings.TrimSpace(string(field.Tag)):
-		log.Debug("option %v set ignore", field.Name)
-		return
-	case skip:
-		log.Debug("option %v set skip", field.Name)
-		return
-	default:
-		switch field.Type.Kind() {
-		case reflect.Bool:
-			var flip *FlipFlag
-			if flip, err = opt.new_flip_flag_arg(value, field); err != nil {
-				// cannot create ff option
-				return
-			}
-			flip.required = required
-			option = flip
-		case reflect.Ptr:
-			// may sub-command or argument
-			_, flag := tags[TAG_FLAG]
-			switch {
-			case flag:
-				var flip *FlipFlag
-				// force set as flag
-				if flip, err = opt.new_flip_flag_arg(value, field); err != nil {
-					// cannot create ff option
-					err = fmt.Errorf("cannot create option %v: %v", field.Name, err)
-					return
-				}
-				flip.required = required
-				option = flip
-			case field.Type.Elem().Kind() == reflect.Struct:
-				ref := value
-				if value.IsZero() {
-					// create dummy instance, and not set back
-					value = reflect.New(field.Type.Elem())
-					log.Trace("create dummy instance from %v: %v", field.Type.Elem(), value)
-				}
-
-				var sub *StructOpt
-				if sub, err = New(value.Interface()); err != nil {
-					log.Warn("create sub-command from %v: %v", field.Type.Elem(), err)
-					err = fmt.Errorf("create sub-command from %v: %v", field.Type.Elem(), err)
-					return
-				}
-
-				if name := field.Tag.Get(TAG_NAME); name != "" {
-					// override the name
-					sub.name = name
-				}
-				sub.ref = ref
-				sub.help = field.Tag.Get(TAG_HELP)
-				option = sub
-			default:
-				var args *FlipFlag
-
-				if args, err = opt.new_flip_flag_arg(value, field); err != nil {
-					// cannot create ff option
-					err = fmt.Errorf("cannot create option %v: %v", field.Name, err)
-					return
-				}
-				args.option_type = Argument
-				args.required = required
-				option = args
-			}
-		default:
-			var flip *FlipFlag
-			if flip, err = opt.new_flip_flag_arg(value, field); err != nil {
-				// cannot create ff option
+		sub.name = field.name
+		sub.ref = ref
+		sub.ref_initial = reflect.ValueOf(ref.Interface())
+		sub.help = field.Tag.Get(TAG_HELP)
+		if sub.confirm = field.Tag.Get(TAG_CONFIRM); sub.confirm != "" {
+			// the confirmed sub-command can be bypassed by its --yes option
+			if err = sub.new_yes_option(); err != nil {
+				err = fmt.Errorf("cannot set sub-command %v: %v", sub.name, err)
 				return
 			}
-			flip.required = required
-			option = flip
 		}
+		option = sub
+	default:
+		var flip *FlipFlag
+		if flip, err = opt.new_flip_flag_arg(value, field.flag); err != nil {
+			// cannot create ff option
+			return
+		}
+		option = flip
 	}
 
 	// setup the callback
@@ -236,105 +248,117 @@ func (opt *StructOpt) new_option(based reflect.Value, value reflect.Value, field
 		log.Debug("add new short named option: %v", name)
 	}
 
+	if field.companion != nil {
+		// add the --x-file companion option
+		if err = opt.new_file_companion(option.(*FlipFlag), field.companion); err != nil {
+			err = fmt.Errorf("cannot set option %v: %v", option.Name(), err)
+			return
+		}
+	}
+
 	return
 }
 
-func (opt *StructOpt) new_flip_flag_arg(value reflect.Value, field reflect.StructField) (option *FlipFlag, err error) {
-	elm := value
-	typ := field.Type
-	log.Trace("try create option from %v (%v)", field.Name, value)
-
-	for elm.Kind() == reflect.Ptr {
-		switch {
-		case elm.IsZero():
-			elm = reflect.New(typ.Elem()).Elem()
-		default:
-			elm = elm.Elem()
-		}
+// bind the analyzed flip, flag or argument to the value, and set the default
+func (opt *StructOpt) new_flip_flag_arg(value reflect.Value, schema *flag_schema) (option *FlipFlag, err error) {
+	var shown interface{} = value
+	if schema.secret {
+		// never log the secret already set in the struct
+		shown = SECRET_MASK
 	}
+	log.Trace("try create option from %v (%v)", schema.name, shown)
 
 	option = &FlipFlag{
-		Value:     value,
-		StructTag: field.Tag,
+		Value:       value,
+		flag_schema: schema,
 
-		name: strings.ToLower(field.Name),
+		setting: opt.setting,
 	}
-	if value.IsValid() && !value.IsZero() {
-		// set the default value
-		option.default_value = fmt.Sprintf("%v", value)
-	}
-
-	if val := option.StructTag.Get(TAG_CHOICE); val != "" {
-		choices := strings.Split(val, " ")
-		sort.Strings(choices)
-		option.choices = choices
-	}
-
-	log.Debug("try create option %v: %T (kind: %v)", option.Name(), elm.Interface(), elm.Kind())
-	switch elm.Interface().(type) {
-	case os.File:
-		// the flag / os.File
-		option.option_type = Flag
-		option.option_type_hint = FILE
-	case os.FileMode:
-		// the flag / os.FileMode
-		option.option_type = Flag
-		option.option_type_hint = FMODE
-	case time.Time:
-		// the flag / os.File
-		option.option_type = Flag
-		option.option_type_hint = TIME
-	case time.Duration:
-		// the flag / os.File
-		option.option_type = Flag
-		option.option_type_hint = SPAN
-	case net.Interface:
-		// the flag / net.Interface
-		option.option_type = Flag
-		option.option_type_hint = IFACE
-	case net.IP:
-		// the flag / net.IP
-		option.option_type = Flag
-		option.option_type_hint = IP
-	case net.IPNet:
-		// the flag / net.IPNet
-		option.option_type = Flag
-		option.option_type_hint = CIDR
-	default:
-		switch elm.Kind() {
-		case reflect.Bool:
-			option.option_type = Flip
-			option.option_type_hint = NONE
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			option.option_type = Flag
-			option.option_type_hint = INT
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			option.option_type = Flag
-			option.option_type_hint = UINT
-		case reflect.Float32, reflect.Float64:
-			// the flag / sign-rational number
-			option.option_type = Flag
-			option.option_type_hint = RAT
-		case reflect.String:
-			option.option_type = Flag
-			option.option_type_hint = STR
-		default:
-			log.Warn("not implemented: %v (type: %v, kind: %v) as flag", field.Name, typ, elm.Kind())
-			err = fmt.Errorf("not implemented: %v (%v)", typ, elm.Kind())
-			return
+
+	if !value.IsZero() {
+		elm := value
+		for elm.Kind() == reflect.Ptr {
+			switch {
+			case elm.IsZero():
+				elm = reflect.New(elm.Type().Elem()).Elem()
+			default:
+				elm = elm.Elem()
+			}
 		}
+
+		// set the default value
+		option.default_value = option.format_value(elm)
+		option.defaulted = option.multiple || option.option_type_hint == IPSET
 	}
 
 	// set the default if provided by TAG
-	if dvalue := field.Tag.Get(TAG_DEFAULT); dvalue != "" {
+	if dvalue := option.Get(TAG_DEFAULT); dvalue != "" {
 		// override the default_value if set in the TAG
 		option.default_value = dvalue
-		// then set as default
-		_, err = option.Set(dvalue)
-		log.Info("override the %v default: %v (%v)", field.Name, dvalue, err)
-		if err != nil {
-			err = fmt.Errorf("invalid %v default value %v: %v", field.Name, dvalue, err)
-			return
+
+		dvalues := []string{dvalue}
+		if option.multiple {
+			// the repeatable option, separate by space
+			dvalues = strings.Fields(dvalue)
+		}
+
+		// then set as default, the secret is never read from the source
+		for _, dvalue := range dvalues {
+			switch option.option_type {
+			case Flip:
+				_, err = option.Set(dvalue)
+			default:
+				err = option.set_arg(dvalue)
+			}
+
+			if option.secret {
+				// never log the secret
+				dvalue = SECRET_MASK
+			}
+			log.Info("override the %v default: %v (%v)", option.Name(), dvalue, err)
+			if err != nil {
+				err = fmt.Errorf("invalid %v default value %v: %v", option.Name(), dvalue, err)
+				return
+			}
+		}
+		option.defaulted = option.multiple || option.option_type_hint == IPSET
+
+		if option.option_type_hint == BYTES {
+			// mask the bytes, like the key, shown as the length only
+			value := option.Value
+			for value.Kind() == reflect.Ptr {
+				value = value.Elem()
+			}
+			option.default_value = option.format_value(value)
+		}
+	}
+
+	// the initial state used in Reset
+	option.initial = clone_value(value)
+	option.initial_defaulted = option.defaulted
+	return
+}
+
+// copy the struct before parsing used in Fork, the ignored and unexported fields are shared
+func snapshot_struct(value reflect.Value) (copied reflect.Value) {
+	copied = reflect.New(value.Type()).Elem()
+	copied.Set(value)
+
+	for idx := 0; idx < value.NumField(); idx++ {
+		field := value.Type().Field(idx)
+		_, skip := option_tags(field)[TAG_SKIP]
+
+		switch {
+		case !copied.Field(idx).CanSet(), skip, strings.TrimSpace(string(field.Tag)) == TAG_IGNORE:
+			// not the option, share the value
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct &&
+			!is_value_type(field.Type.Elem()) && !value.Field(idx).IsNil():
+			// the sub-command
+			sub := reflect.New(field.Type.Elem())
+			sub.Elem().Set(snapshot_struct(value.Field(idx).Elem()))
+			copied.Field(idx).Set(sub)
+		default:
+			copied.Field(idx).Set(clone_value(value.Field(idx)))
 		}
 	}
 	return
@@ -370,41 +394,178 @@ func (opt *StructOpt) set_callback(based reflect.Value, fn string, option Option
 	return
 }
 
+// Set the clock used to calculate the relative time, like -2h or yesterday.
+func (opt *StructOpt) SetClock(now func() time.Time) {
+	opt.setting.now = now
+}
+
+// Close all the files opened by the options, except the stdin / stdout.
+func (opt *StructOpt) Close() (err error) {
+	for _, closer := range opt.setting.closers {
+		if e := closer.Close(); e != nil && err == nil {
+			// return the first error
+			err = e
+		}
+	}
+
+	opt.setting.closers = nil
+	opt.setting.defaults = 0
+	return
+}
+
+// Restore every option to the initial state, the value before parsing or set by the default, and
+// close the files opened in the parsing. The files opened by the default are kept until Close.
+func (opt *StructOpt) Reset() (err error) {
+	closers := opt.setting.closers[opt.setting.defaults:]
+	for _, closer := range closers {
+		if e := closer.Close(); e != nil && err == nil {
+			// return the first error
+			err = e
+		}
+	}
+	opt.setting.closers = opt.setting.closers[:opt.setting.defaults]
+
+	opt.reset()
+	return
+}
+
+// restore the options of the parser and its sub-commands
+func (opt *StructOpt) reset() {
+	for _, options := range [][]Option{opt.ff_options, opt.arg_options, opt.sub_options} {
+		for _, option := range options {
+			switch option := option.(type) {
+			case *FlipFlag:
+				option.reset()
+			case *StructOpt:
+				option.reset()
+			}
+		}
+	}
+
+	if opt.ref.IsValid() {
+		// restore the sub-command in the parent struct
+		opt.ref.Set(opt.ref_initial)
+	}
+}
+
+// Generate the parser of the fresh instance, the copy of the input struct before parsing, and
+// share the same setting (like the clock and resolver) except the opened files.
+func (opt *StructOpt) Fork() (fork *StructOpt, err error) {
+	setting := *opt.setting
+	setting.closers = nil
+	setting.yes = false
+
+	value := reflect.New(opt.Value.Elem().Type())
+	value.Elem().Set(clone_value(opt.initial))
+	if fork, err = new_struct_opt(value.Interface(), &setting); err != nil {
+		// cannot generate the parser
+		return
+	}
+
+	fork.name = opt.name
+	fork.help = opt.help
+	fork.setting.defaults = len(fork.setting.closers)
+	return
+}
+
+// Set the hostname resolver used in the IP option with the resolve tag.
+func (opt *StructOpt) SetResolver(resolver Resolver) {
+	opt.setting.resolver = resolver
+}
+
+// Set the network interface provider used in the IFACE option.
+func (opt *StructOpt) SetInterfaces(provider InterfaceProvider) {
+	opt.setting.ifaces = provider
+}
+
+// Register the functions used in the TMPL option, should be called before parsing. The default of
+// the TMPL option is parsed in New, use NewWithFuncs if the default uses the functions.
+func (opt *StructOpt) SetFuncs(funcs template.FuncMap) {
+	if opt.setting.funcs == nil {
+		opt.setting.funcs = template.FuncMap{}
+	}
+
+	for name, fn := range funcs {
+		opt.setting.funcs[name] = fn
+	}
+}
+
+// Set the standard input used in the BYTES option (like @-) and the prompt
+func (opt *StructOpt) SetStdin(stdin io.Reader) {
+	opt.setting.stdin = stdin
+}
+
+// Set the standard error used in the warning, prompt and usage
+func (opt *StructOpt) SetStderr(stderr io.Writer) {
+	opt.setting.stderr = stderr
+}
+
+// Enable expanding the response files (@path) in Run, see ExpandResponseFiles.
+func (opt *StructOpt) SetResponseFiles(enable bool) {
+	opt.setting.response_files = enable
+}
+
+// Enable asking the missing required options and arguments when the stdin is the terminal, or the
+// reader set by SetStdin, instead of returning the error.
+func (opt *StructOpt) SetPrompt(enable bool) {
+	opt.setting.prompt = enable
+}
+
+// Set the width to wrap the usage, detected by $COLUMNS or the terminal if zero.
+func (opt *StructOpt) SetWidth(width int) {
+	opt.setting.width = width
+}
+
 // Syntax-sugar for show help message
 func (opt *StructOpt) Help(option Option) {
-	os.Stderr.WriteString(opt.Usage())
-	os.Exit(0)
+	fmt.Fprint(opt.stderr, opt.Usage())
+	opt.exit(0)
 }
 
 // Run as default command-line parser, read from os.Args and show error and usage when parse error.
 func (opt *StructOpt) Run() {
-	if _, err := opt.Set(os.Args[1:]...); err != nil {
-		os.Stderr.WriteString(fmt.Sprintf("%v\n%v", err, opt.Usage()))
+	args := os.Args[1:]
+	if opt.response_files {
+		var err error
+
+		if args, err = ExpandResponseFiles(args); err != nil {
+			fmt.Fprintf(opt.stderr, "error: %v\n%v", err, opt.Usage())
+			// and then exit the program
+			opt.exit(1)
+			return
+		}
+	}
+
+	if _, err := opt.Set(args...); err != nil {
+		fmt.Fprintf(opt.stderr, "error: %v\n%v", err, opt.Usage())
 		// and then exit the program
-		os.Exit(1)
+		opt.exit(1)
 	}
 }
 
+// Check the required options and all arguments, exit program if not matched
 func (opt *StructOpt) CheckRequired() {
-	defer func() {
-		if r := recover(); r != nil {
-			os.Stderr.WriteString(fmt.Sprintf("%v\n%v", r, opt.Usage()))
-			// and then exit the program
-			os.Exit(1)
-		}
-	}()
+	if err := opt.check_required(); err != nil {
+		fmt.Fprintf(opt.stderr, "error: %v\n%v", err, opt.Usage())
+		// and then exit the program
+		opt.exit(1)
+	}
+}
 
-	// The check the required and all arguments
+// check the required options and all arguments, return the first missing one which is not asked
+func (opt *StructOpt) check_required() (err error) {
 	for _, option := range opt.ff_options {
-		if option.IsRequired() && option.IsZero() {
-			err := fmt.Errorf("error: --%v is required", strings.ToLower(option.Name()))
-			panic(err)
+		if option.IsRequired() && option.IsZero() && !opt.prompt_missing(option) {
+			err = fmt.Errorf("--%v is required", strings.ToLower(option.Name()))
+			return
 		}
 	}
+
 	for _, argument := range opt.arg_options {
-		if argument.IsZero() {
-			err := fmt.Errorf("error: %v is required", strings.ToUpper(argument.Name()))
-			panic(err)
+		if argument.IsZero() && !opt.prompt_missing(argument) {
+			err = fmt.Errorf("%v is required", strings.ToUpper(argument.Name()))
+			return
 		}
 	}
+	return
 }
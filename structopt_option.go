@@ -30,6 +30,10 @@ func (opt *StructOpt) Usage() (str string) {
 	for _, option := range opt.arg_options {
 		// add argument
 		usage = fmt.Sprintf("%v %v", usage, strings.ToUpper(option.Name()))
+		if is_multiple(option) {
+			// the repeatable argument
+			usage = fmt.Sprintf("%v...", usage)
+		}
 	}
 
 	if len(opt.sub_options) > 0 {
@@ -39,13 +43,20 @@ func (opt *StructOpt) Usage() (str string) {
 
 	help_message = append(help_message, usage)
 
+	// align the columns of all the rows
+	var options []Option
+	for _, rows := range [][]Option{opt.ff_options, opt.arg_options, opt.sub_options} {
+		options = append(options, rows...)
+	}
+	layout := new_usage_layout(options, opt.usage_width())
+
 	if len(opt.ff_options) > 0 {
 		help_message = append(help_message, "")
 		help_message = append(help_message, "options:")
 
 		for _, option := range opt.ff_options {
 			// add the option row
-			help_message = append(help_message, option.String())
+			help_message = append(help_message, layout.row(option))
 		}
 	}
 
@@ -55,7 +66,7 @@ func (opt *StructOpt) Usage() (str string) {
 
 		for _, option := range opt.arg_options {
 			// add the option row
-			help_message = append(help_message, option.String())
+			help_message = append(help_message, layout.row(option))
 		}
 	}
 
@@ -65,7 +76,7 @@ func (opt *StructOpt) Usage() (str string) {
 
 		for _, option := range opt.sub_options {
 			// add the option row
-			help_message = append(help_message, option.String())
+			help_message = append(help_message, layout.row(option))
 		}
 	}
 
@@ -73,13 +84,22 @@ func (opt *StructOpt) Usage() (str string) {
 	return
 }
 
+// Show as the single row of the usage
 func (opt *StructOpt) String() (str string) {
-	str = fmt.Sprintf("    %-12v %v", opt.Name(), opt.help)
-	str = strings.TrimRight(str, " ")
+	str = new_usage_layout([]Option{opt}, 0).row(opt)
 	return
 }
 
-// Set the input argument and setup the value for secified fields, or return error.
+// the columns of the usage row, the sub-command has no short name
+func (opt *StructOpt) columns() (short, long string, help []string) {
+	long = opt.Name()
+	help = strings.Fields(opt.help)
+	return
+}
+
+// Set the input argument and setup the value for secified fields, or return error. The missing
+// required option or argument is also returned as the error, and never exits the program like
+// Run and CheckRequired.
 func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 	disable_short_option := false
 	disable_option := false
@@ -89,17 +109,32 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 		opt.ref.Set(opt.Value)
 	}
 
+	for _, options := range [][]Option{opt.ff_options, opt.arg_options} {
+		for _, option := range options {
+			if flip, ok := option.(*FlipFlag); ok {
+				// start the new parsing, used in the mutual exclusion
+				flip.assigned = false
+			}
+		}
+	}
+
 	arg_idx := 0
 	for idx < len(args) {
 		var count int
 
 		arg := args[idx]
-		log.Info("parse #%v argument: %#v", idx, arg)
+
+		shown := arg
+		if arg_idx < len(opt.arg_options) && is_secret(opt.arg_options[arg_idx]) && (disable_option || !strings.HasPrefix(arg, "-")) {
+			// never log the secret argument
+			shown = SECRET_MASK
+		}
+		log.Info("parse #%v argument: %#v", idx, shown)
 
 		switch {
 		case len(arg) == 0:
 			// empty argument, skip
-		case !disable_short_option && arg == "-":
+		case !disable_short_option && arg == "-" && arg_idx >= len(opt.arg_options):
 			// disable short option
 			log.Debug("#%v argument %#v: disable short option", idx, arg)
 		case !disable_option && arg == "--":
@@ -115,11 +150,12 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 				err = fmt.Errorf("unknown option: %v", arg)
 				return
 			} else if count, err = option.Set(args[idx+1:]...); err != nil {
-				// cannot set value
+				// cannot set value, point at the option
+				err = fmt.Errorf("set %v: %v", arg, err)
 				return
 			}
 			idx += count
-		case !disable_short_option && arg[:1] == "-":
+		case !disable_short_option && len(arg) > 1 && arg[:1] == "-":
 			// short option
 			log.Trace("#%v argument %#v", idx, arg)
 			switch len([]rune(arg[1:])) {
@@ -131,7 +167,8 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 					err = fmt.Errorf("unknown option: %v", arg)
 					return
 				} else if count, err = option.Set(args[idx+1:]...); err != nil {
-					// cannot set value
+					// cannot set value, point at the option
+					err = fmt.Errorf("set %v: %v", arg, err)
 					return
 				}
 				idx += count
@@ -154,7 +191,7 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 			// argument
 			switch {
 			case arg_idx < len(opt.arg_options):
-				log.Debug("#%v argument %#v", idx, arg)
+				log.Debug("#%v argument %#v", idx, shown)
 
 				option := opt.arg_options[arg_idx]
 				// NOTE - argument always use one args
@@ -162,7 +199,11 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 					err = fmt.Errorf("set %v: %v", strings.ToUpper(option.Name()), err)
 					return
 				}
-				arg_idx++
+
+				if !is_multiple(option) {
+					// the repeatable argument consume all the remains arguments
+					arg_idx++
+				}
 			default:
 				// sub-command
 				if option, ok := opt.named_options[arg]; !ok {
@@ -180,8 +221,26 @@ func (opt *StructOpt) Set(args ...string) (idx int, err error) {
 		idx++
 	}
 
-	// The check the required and all arguments, exit program if not matched
-	opt.CheckRequired()
+	// The check the required and all arguments
+	if err = opt.check_required(); err != nil {
+		return
+	}
+
+	// and then confirm the dangerous options and sub-command
+	err = opt.check_confirm()
+	return
+}
+
+// Split the line by the shell quoting rules (see SplitWords), and then set as the arguments.
+func (opt *StructOpt) ParseLine(line string) (idx int, err error) {
+	var args []string
+
+	if args, err = SplitWords(line); err != nil {
+		// cannot split the line
+		return
+	}
+
+	idx, err = opt.Set(args...)
 	return
 }
 
@@ -203,6 +262,15 @@ func (opt *StructOpt) SetCallback(fn Callback) {
 	opt.Callback = fn
 }
 
+// the option is repeatable, which the field is slice
+func is_multiple(option Option) (multiple bool) {
+	if flip, ok := option.(*FlipFlag); ok {
+		// only FlipFlag may be repeatable
+		multiple = flip.multiple
+	}
+	return
+}
+
 func (opt *StructOpt) IsRequired() (required bool) {
 	required = false
 	return
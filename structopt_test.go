@@ -1,9 +1,18 @@
 package structopt
 
 import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/big"
 	"net"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"text/template"
 	"time"
 )
 
@@ -58,19 +67,368 @@ func Example() {
 		Now:  time.Now(),
 	}
 	parser := MustNew(&example)
+	parser.SetWidth(80)
 
 	os.Stdout.WriteString(parser.Usage())
 	// Output:
 	// usage: foo [OPTION] [SUB]
 	//
 	// options:
-	//           -h --help          show this message
-	//       -l STR --level STR     set the log level [debug info trace warn]
-	//       -n STR --name STR      please type your name (default: john)
-	//      -a UINT --age UINT      please type your age
-	//      -t TIME --now TIME      type the RFC-3389 time format (default: 2020-01-02T03:04:05Z)
-	//              --cidr CIDR     please type the valid CIDR
+	//          -h --help       show this message
+	//      -l STR --level STR  set the log level [debug info trace warn]
+	//      -n STR --name STR   please type your name (default: john)
+	//     -a UINT --age UINT   please type your age
+	//     -t TIME --now TIME   type the RFC-3389 time format
+	//                          (default: 2020-01-02T03:04:05Z)
+	//             --cidr CIDR  please type the valid CIDR
 	//
 	// sub-commands:
-	//     sub          the sub-command
+	//     sub                  the sub-command
+}
+
+func TestSetError(t *testing.T) {
+	parser := MustNew(&Foo{})
+
+	cases := map[string][]string{
+		"set --level: fatal not in [debug info trace warn]": {"--level", "fatal"},
+		"set -l: fatal not in [debug info trace warn]":      {"-l", "fatal"},
+		"unknown option: --color":                           {"--color", "auto"},
+	}
+	for msg, args := range cases {
+		if _, err := parser.Set(args...); err == nil || err.Error() != msg {
+			t.Errorf("expect set %v fail %#v: %v", args, msg, err)
+		}
+	}
+}
+
+func TestRequiredError(t *testing.T) {
+	origin := os.Args
+	defer func() { os.Args = origin }()
+	os.Args = []string{"rollout", "--verbose"}
+
+	parser := MustNew(&Rollout{})
+	if _, err := parser.Set("--verbose"); err == nil || err.Error() != "--region is required" {
+		t.Errorf("expect Set return the missing required option: %v", err)
+	}
+
+	for name, fn := range map[string]func(){"Run": parser.Run, "CheckRequired": parser.CheckRequired} {
+		var codes []int
+
+		stderr := &strings.Builder{}
+		parser.SetStderr(stderr)
+		parser.setting.exit = func(code int) { codes = append(codes, code) }
+
+		fn()
+		if !reflect.DeepEqual(codes, []int{1}) || !strings.HasPrefix(stderr.String(), "error: --region is required\nusage: ") {
+			t.Errorf("expect %v show the error and exit: %v %#v", name, codes, stderr.String())
+		}
+	}
+}
+
+type Number struct {
+	Int   big.Int
+	Rat   *big.Rat   `option:"flag"`
+	Float *big.Float `help:"the argument keep as big.Float"`
+}
+
+func TestBigNumber(t *testing.T) {
+	number := Number{}
+	parser := MustNew(&number)
+
+	if _, err := parser.Set("--int", "0x_1_0000_0000_0000_0000", "--rat", "3/7", "2.5%"); err != nil {
+		t.Fatalf("cannot set big number: %v", err)
+	}
+
+	switch {
+	case number.Int.String() != "18446744073709551616":
+		t.Errorf("expect --int 2^64: %v", &number.Int)
+	case number.Rat.String() != "3/7":
+		t.Errorf("expect --rat 3/7: %v", number.Rat)
+	case number.Float.String() != "0.025":
+		t.Errorf("expect FLOAT 0.025: %v", number.Float)
+	}
+}
+
+type Query struct {
+	Since time.Time `layout:"date|unix" tz:"UTC" help:"the start time"`
+}
+
+func TestTimeOption(t *testing.T) {
+	query := Query{}
+	parser := MustNew(&query)
+	parser.SetClock(func() time.Time {
+		return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	})
+
+	if _, err := parser.Set("--since", "-2h"); err != nil {
+		t.Fatalf("cannot set --since -2h: %v", err)
+	} else if !query.Since.Equal(time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expect --since -2h: %v", query.Since)
+	}
+
+	if _, err := parser.Set("--since", "2026-10-01"); err != nil {
+		t.Fatalf("cannot set --since 2026-10-01: %v", err)
+	} else if !query.Since.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expect --since 2026-10-01: %v", query.Since)
+	}
+
+	help := "    --since TIME  the start time (layout: 2006-01-02|unix)"
+	if str := parser.named_options["since"].String(); str != help {
+		t.Errorf("expect help %#v: %#v", help, str)
+	}
+}
+
+type Schedule struct {
+	TZ      *time.Location `option:"flag"`
+	Month   time.Month
+	Weekday time.Weekday `default:"mon"`
+}
+
+func TestScheduleOption(t *testing.T) {
+	schedule := Schedule{}
+	parser := MustNew(&schedule)
+
+	if _, err := parser.Set("--tz", "UTC", "--month", "march"); err != nil {
+		t.Fatalf("cannot set the schedule: %v", err)
+	}
+
+	switch {
+	case schedule.TZ != time.UTC:
+		t.Errorf("expect --tz UTC: %v", schedule.TZ)
+	case schedule.Month != time.March:
+		t.Errorf("expect --month March: %v", schedule.Month)
+	case schedule.Weekday != time.Monday:
+		t.Errorf("expect default --weekday Monday: %v", schedule.Weekday)
+	}
+}
+
+type Archive struct {
+	Exclude []string `short:"e" default:"*.tmp *.swp"`
+	Level   []uint   `unit:"si"`
+
+	Files *[]string `path:"file" glob:"strict" help:"the files to archive"`
+}
+
+func TestRepeatableOption(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("cannot create %v: %v", name, err)
+		}
+	}
+
+	archive := Archive{}
+	parser := MustNew(&archive)
+
+	if !reflect.DeepEqual(archive.Exclude, []string{"*.tmp", "*.swp"}) {
+		t.Errorf("expect default --exclude: %v", archive.Exclude)
+	}
+
+	args := []string{"-e", "*.bak", "--level", "1k", "--level", "2", filepath.Join(dir, "*.log"), "README.md"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	files := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log"), "README.md"}
+	switch {
+	case !reflect.DeepEqual(archive.Exclude, []string{"*.bak"}):
+		t.Errorf("expect --exclude replace the default: %v", archive.Exclude)
+	case !reflect.DeepEqual(archive.Level, []uint{1000, 2}):
+		t.Errorf("expect --level 1k 2: %v", archive.Level)
+	case archive.Files == nil || !reflect.DeepEqual(*archive.Files, files):
+		t.Errorf("expect FILES %v: %v", files, archive.Files)
+	}
+
+	if _, err := parser.Set(filepath.Join(dir, "*.none")); err == nil {
+		t.Errorf("expect no matches found")
+	}
+
+	if usage := parser.Usage(); !strings.HasPrefix(usage, "usage: archive [OPTION] FILES...\n") {
+		t.Errorf("expect repeatable argument in usage: %v", usage)
+	}
+}
+
+type Render struct {
+	Filter  regexp.Regexp      `short:"f" default:"^api-"`
+	Format  *template.Template `option:"flag"`
+	Extra   json.RawMessage
+	Labels  map[string]string `option:"json"`
+	Backend *struct {
+		Host string
+		Port int
+	} `option:"json,flag"`
+}
+
+func TestRenderOption(t *testing.T) {
+	render := Render{}
+	parser := MustNew(&render)
+	parser.SetFuncs(template.FuncMap{"upper": strings.ToUpper})
+
+	args := []string{"--format", "{{.Name | upper}}", "--extra", `{"a": 1}`, "--labels", `{"env": "prod"}`, "--backend", `{"Host": "db", "Port": 5432}`}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	buff := &strings.Builder{}
+	if render.Format == nil || render.Format.Execute(buff, struct{ Name string }{"api"}) != nil || buff.String() != "API" {
+		t.Errorf("expect --format with the registered functions: %v", buff)
+	}
+
+	if render.Format.Lookup("format") != render.Format {
+		t.Errorf("expect --format keep the parsed template: %v", render.Format.Lookup("format"))
+	}
+
+	switch {
+	case !render.Filter.MatchString("api-v1") || render.Filter.MatchString("web-api"):
+		t.Errorf("expect --filter default: %v", &render.Filter)
+	case string(render.Extra) != `{"a": 1}`:
+		t.Errorf("expect --extra: %s", render.Extra)
+	case !reflect.DeepEqual(render.Labels, map[string]string{"env": "prod"}):
+		t.Errorf("expect --labels: %v", render.Labels)
+	case render.Backend == nil || render.Backend.Host != "db" || render.Backend.Port != 5432:
+		t.Errorf("expect --backend: %v", render.Backend)
+	}
+
+	cases := [][]string{
+		{"-f", "api-("},
+		{"--format", "{{.Name | lower}}"},
+		{"--extra", `{"a": 1`},
+		{"--labels", `["prod"]`},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil || !strings.Contains(err.Error(), args[0]) {
+			// expect failure with the option name
+			t.Errorf("expect cannot set %v: %v", args, err)
+		}
+	}
+
+	if _, err := New(&struct{ Format template.Template }{}); err == nil {
+		t.Errorf("expect cannot copy the template by value")
+	}
+}
+
+type Banner struct {
+	Title *template.Template `option:"flag" default:"{{.Name | upper}}"`
+}
+
+func TestTemplateFuncsDefault(t *testing.T) {
+	if _, err := New(&Banner{}); err == nil {
+		t.Errorf("expect cannot parse the default with the unknown function")
+	}
+
+	banner := Banner{}
+	if _, err := NewWithFuncs(&banner, template.FuncMap{"upper": strings.ToUpper}); err != nil {
+		t.Fatalf("cannot parse the default with the functions: %v", err)
+	}
+
+	buff := &strings.Builder{}
+	if err := banner.Title.Execute(buff, struct{ Name string }{"api"}); err != nil || buff.String() != "API" {
+		t.Errorf("expect the default --title with the functions: %v (%v)", buff, err)
+	}
+}
+
+type ServerRun struct {
+	Workers int `default:"4"`
+}
+
+type Server struct {
+	Verbose bool     `short:"v"`
+	Name    string   `default:"web"`
+	Tags    []string `default:"a b"`
+	Limit   big.Int  `default:"100"`
+	Targets IPSet
+	Backup  *string        `option:"flag"`
+	Zone    *time.Location `option:"flag" default:"UTC"`
+	Run     *ServerRun     `name:"run"`
+}
+
+func TestResetAndFork(t *testing.T) {
+	initial := "backup.tar"
+	server := Server{Backup: &initial}
+	parser := MustNew(&server)
+
+	args := []string{"-v", "--name", "api", "--tags", "c", "--limit", "200", "--targets", "10.0.0.1", "--backup", "other.tar", "run", "--workers", "8"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	if err := parser.Reset(); err != nil {
+		t.Fatalf("cannot reset: %v", err)
+	}
+	switch {
+	case server.Verbose || server.Name != "web":
+		t.Errorf("expect reset --verbose and --name: %v %v", server.Verbose, server.Name)
+	case !reflect.DeepEqual(server.Tags, []string{"a", "b"}):
+		t.Errorf("expect reset --tags: %v", server.Tags)
+	case server.Limit.Int64() != 100 || server.Targets.String() != "":
+		t.Errorf("expect reset --limit and --targets: %v %v", &server.Limit, &server.Targets)
+	case server.Backup == nil || *server.Backup != "backup.tar":
+		t.Errorf("expect reset --backup: %v", server.Backup)
+	case server.Run != nil:
+		t.Errorf("expect reset the sub-command: %v", server.Run)
+	case server.Zone != time.UTC:
+		t.Errorf("expect reset --zone keep the shared location: %p", server.Zone)
+	}
+
+	if _, err := parser.Set("--tags", "d", "run"); err != nil || !reflect.DeepEqual(server.Tags, []string{"d"}) || server.Run.Workers != 4 {
+		t.Errorf("expect replace the default after reset: %v %v (%v)", server.Tags, server.Run, err)
+	}
+
+	fork, err := parser.Fork()
+	if err != nil {
+		t.Fatalf("cannot fork: %v", err)
+	}
+
+	if _, err := fork.Set("--name", "forked", "--backup", "forked.tar"); err != nil {
+		t.Fatalf("cannot set the fork: %v", err)
+	}
+
+	forked := fork.Value.Interface().(*Server)
+	switch {
+	case forked == &server || forked.Name != "forked" || *forked.Backup != "forked.tar":
+		t.Errorf("expect set the fresh instance: %v", forked)
+	case server.Name != "web" || *server.Backup != "backup.tar":
+		t.Errorf("expect not modify the original instance: %v %v", server.Name, *server.Backup)
+	case !reflect.DeepEqual(forked.Tags, []string{"a", "b"}) || forked.Run != nil:
+		t.Errorf("expect the fork from the initial state: %v %v", forked.Tags, forked.Run)
+	}
+}
+
+type Transfer struct {
+	Input  *os.File `option:"flag" default:"-"`
+	Output io.Writer
+}
+
+func TestResetCloseFiles(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "input"), filepath.Join(dir, "output")
+	if err := ioutil.WriteFile(input, []byte("data"), 0644); err != nil {
+		t.Fatalf("cannot create %v: %v", input, err)
+	}
+
+	copied := Transfer{}
+	parser := MustNew(&copied)
+	defer parser.Close()
+
+	for idx := 0; idx < 2; idx++ {
+		if _, err := parser.Set("--input", input, "--output", output); err != nil {
+			t.Fatalf("cannot set #%d: %v", idx, err)
+		}
+
+		file := copied.Input
+		if _, err := copied.Output.Write([]byte("data")); err != nil {
+			t.Fatalf("cannot write --output: %v", err)
+		}
+
+		switch err := parser.Reset(); {
+		case err != nil:
+			t.Fatalf("cannot reset #%d: %v", idx, err)
+		case len(parser.closers) != 0:
+			t.Errorf("expect clear the closers: %v", parser.closers)
+		case file.Close() == nil:
+			t.Errorf("expect close --input when reset")
+		case copied.Input != os.Stdin || copied.Output != nil:
+			t.Errorf("expect restore the default: %v %v", copied.Input, copied.Output)
+		}
+	}
 }
@@ -0,0 +1,12 @@
+//go:build darwin
+// +build darwin
+
+package structopt
+
+import "syscall"
+
+// the ioctl request to get / set the terminal attributes
+const (
+	ioctl_get_termios = syscall.TIOCGETA
+	ioctl_set_termios = syscall.TIOCSETA
+)
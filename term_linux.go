@@ -0,0 +1,12 @@
+//go:build linux
+// +build linux
+
+package structopt
+
+import "syscall"
+
+// the ioctl request to get / set the terminal attributes
+const (
+	ioctl_get_termios = syscall.TCGETS
+	ioctl_set_termios = syscall.TCSETS
+)
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package structopt
+
+import (
+	"os"
+)
+
+// the terminal detection is not supported, always treat as non-terminal
+func is_terminal(file *os.File) (ok bool) {
+	return
+}
+
+// the no-echo input is not supported, read the line as-is
+func read_password(file *os.File) (line string, err error) {
+	line, err = read_line(file)
+	return
+}
+
+// the terminal width is not supported, always use the default width
+func terminal_width(file *os.File) (width int) {
+	return
+}
@@ -0,0 +1,62 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package structopt
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// the file is the terminal or not
+func is_terminal(file *os.File) (ok bool) {
+	var termios syscall.Termios
+
+	ok = ioctl_termios(file.Fd(), ioctl_get_termios, &termios) == nil
+	return
+}
+
+// read the line from the terminal without echo, and restore the terminal when finished
+func read_password(file *os.File) (line string, err error) {
+	var termios syscall.Termios
+
+	fd := file.Fd()
+	if err = ioctl_termios(fd, ioctl_get_termios, &termios); err != nil {
+		// not the terminal
+		line, err = read_line(file)
+		return
+	}
+
+	silent := termios
+	silent.Lflag &^= syscall.ECHO
+	silent.Lflag |= syscall.ICANON | syscall.ISIG
+	if err = ioctl_termios(fd, ioctl_set_termios, &silent); err != nil {
+		return
+	}
+	defer ioctl_termios(fd, ioctl_set_termios, &termios) // nolint: errcheck
+
+	line, err = read_line(file)
+	return
+}
+
+func ioctl_termios(fd uintptr, request uintptr, termios *syscall.Termios) (err error) {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios))); errno != 0 {
+		err = errno
+	}
+	return
+}
+
+// the columns of the terminal, or zero if not the terminal
+func terminal_width(file *os.File) (width int) {
+	var size struct {
+		row, col       uint16
+		xpixel, ypixel uint16
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&size)))
+	if errno == 0 {
+		width = int(size.col)
+	}
+	return
+}
@@ -0,0 +1,183 @@
+package structopt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The POSIX shell-words tokenizer, which split the text into the words by the single quote,
+// double quote, backslash escape and the line continuation (backslash-newline).
+type Tokenizer struct {
+	// The # starts the comment until the end of line
+	Comments bool
+	// Expand the $NAME and ${NAME} outside the single quote, like os.Getenv, the expanded
+	// value is never split into words
+	Expand func(name string) string
+}
+
+// Split the text into the words by the shell quoting rules, without comment and expansion.
+func SplitWords(text string) (words []string, err error) {
+	words, err = Tokenizer{}.Split(text)
+	return
+}
+
+// Split the text into the words, or return the error with the position, like the unbalanced quote.
+func (tokenizer Tokenizer) Split(text string) (words []string, err error) {
+	var word strings.Builder
+
+	runes := []rune(text)
+	in_word := false
+	quote, quote_at := rune(0), 0
+
+	for idx := 0; idx < len(runes); idx++ {
+		ch := runes[idx]
+
+		switch {
+		case quote == '\'':
+			if ch == '\'' {
+				// close the single quote
+				quote = 0
+				continue
+			}
+			word.WriteRune(ch)
+		case quote == '"':
+			switch {
+			case ch == '"':
+				// close the double quote
+				quote = 0
+			case ch == '$' && tokenizer.Expand != nil:
+				var value string
+
+				if value, idx, err = tokenizer.expand(runes, idx); err != nil {
+					return
+				}
+				word.WriteString(value)
+			case ch == '\\' && idx+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[idx+1]):
+				// the escaped character in the double quote
+				idx++
+				if runes[idx] != '\n' {
+					word.WriteRune(runes[idx])
+				}
+			default:
+				word.WriteRune(ch)
+			}
+		case ch == '\\':
+			switch {
+			case idx+1 == len(runes):
+				err = fmt.Errorf("trailing backslash at %v", position(runes, idx))
+				return
+			case runes[idx+1] == '\n':
+				// the line continuation
+				idx++
+			case runes[idx+1] == '\r' && idx+2 < len(runes) && runes[idx+2] == '\n':
+				// the line continuation with CRLF
+				idx += 2
+			default:
+				idx++
+				word.WriteRune(runes[idx])
+				in_word = true
+			}
+		case ch == '\'' || ch == '"':
+			quote, quote_at = ch, idx
+			in_word = true
+		case ch == '$' && tokenizer.Expand != nil:
+			var value string
+
+			if value, idx, err = tokenizer.expand(runes, idx); err != nil {
+				return
+			}
+
+			// the empty expansion is not the word, like the shell
+			word.WriteString(value)
+			in_word = in_word || value != ""
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if in_word {
+				// end of the word
+				words = append(words, word.String())
+				word.Reset()
+				in_word = false
+			}
+		case ch == '#' && tokenizer.Comments && !in_word:
+			for idx < len(runes) && runes[idx] != '\n' {
+				// skip the comment until the end of line
+				idx++
+			}
+		default:
+			word.WriteRune(ch)
+			in_word = true
+		}
+	}
+
+	if quote != 0 {
+		err = fmt.Errorf("unbalanced quote %c at %v", quote, position(runes, quote_at))
+		return
+	}
+
+	if in_word {
+		// the last word
+		words = append(words, word.String())
+	}
+	return
+}
+
+// expand the variable started at the $, and return the index of the last rune of the variable.
+// The $ not followed by the name is kept as-is.
+func (tokenizer Tokenizer) expand(runes []rune, idx int) (value string, last int, err error) {
+	is_name := func(ch rune, first bool) bool {
+		return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (!first && ch >= '0' && ch <= '9')
+	}
+
+	switch {
+	case idx+1 < len(runes) && runes[idx+1] == '{':
+		end := idx + 2
+		for end < len(runes) && runes[end] != '}' {
+			end++
+		}
+
+		if end == len(runes) {
+			err = fmt.Errorf("unbalanced brace { at %v", position(runes, idx+1))
+			return
+		}
+
+		name := string(runes[idx+2 : end])
+		valid := name != ""
+		for pos, ch := range name {
+			valid = valid && is_name(ch, pos == 0)
+		}
+
+		if !valid {
+			err = fmt.Errorf("invalid variable ${%v} at %v", name, position(runes, idx))
+			return
+		}
+
+		value, last = tokenizer.Expand(name), end
+	case idx+1 < len(runes) && is_name(runes[idx+1], true):
+		end := idx + 1
+		for end < len(runes) && is_name(runes[end], false) {
+			end++
+		}
+
+		value, last = tokenizer.Expand(string(runes[idx+1:end])), end-1
+	default:
+		// the literal $
+		value, last = "$", idx
+	}
+	return
+}
+
+// the human-readable position of the rune, like line 2, column 5
+func position(runes []rune, idx int) (pos string) {
+	line, column := 1, 1
+	for _, ch := range runes[:idx] {
+		switch ch {
+		case '\n':
+			line++
+			column = 1
+		default:
+			column++
+		}
+	}
+
+	pos = fmt.Sprintf("line %v, column %v", line, column)
+	return
+}
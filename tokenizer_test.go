@@ -0,0 +1,115 @@
+package structopt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	cases := []struct {
+		text  string
+		words []string
+	}{
+		{"", nil},
+		{"  --name  foo\tbar\n", []string{"--name", "foo", "bar"}},
+		{`'single  quote' "double  quote"`, []string{"single  quote", "double  quote"}},
+		{`'it'\''s' "say \"hi\" \$HOME \n"`, []string{"it's", `say "hi" $HOME \n`}},
+		{`escaped\ space a\\b ""`, []string{"escaped space", `a\b`, ""}},
+		{"--name \\\n  foo \\\r\nbar", []string{"--name", "foo", "bar"}},
+		{"--name # the comment\n# the line comment\nfoo#bar", []string{"--name", "foo#bar"}},
+		{`"multi
+line"`, []string{"multi\nline"}},
+	}
+
+	tokenizer := Tokenizer{Comments: true}
+	for _, c := range cases {
+		words, err := tokenizer.Split(c.text)
+		switch {
+		case err != nil:
+			t.Errorf("cannot split %#v: %v", c.text, err)
+		case !reflect.DeepEqual(words, c.words):
+			t.Errorf("split %#v = %#v: %#v", c.text, words, c.words)
+		}
+	}
+
+	if words, _ := SplitWords("a # b"); !reflect.DeepEqual(words, []string{"a", "#", "b"}) {
+		t.Errorf("expect keep the # without comments: %#v", words)
+	}
+
+	errors := map[string]string{
+		`foo 'bar`:         "unbalanced quote ' at line 1, column 5",
+		"foo\n  \"bar":     "unbalanced quote \" at line 2, column 3",
+		`foo bar\`:         "trailing backslash at line 1, column 8",
+		`"foo" 'bar' "baz`: "unbalanced quote \" at line 1, column 13",
+	}
+	for text, msg := range errors {
+		if _, err := tokenizer.Split(text); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expect cannot split %#v: %v", text, err)
+		}
+	}
+}
+
+func TestTokenizerExpand(t *testing.T) {
+	env := map[string]string{"HOME": "/home/user", "EMPTY": ""}
+	tokenizer := Tokenizer{Expand: func(name string) string { return env[name] }}
+
+	cases := []struct {
+		text  string
+		words []string
+	}{
+		{"$HOME/bin ${HOME}_x", []string{"/home/user/bin", "/home/user_x"}},
+		{`'$HOME' "$HOME" \$HOME`, []string{"$HOME", "/home/user", "$HOME"}},
+		{`$EMPTY "$EMPTY" $ a$1 $UNKNOWN`, []string{"", "$", "a$1"}},
+	}
+
+	for _, c := range cases {
+		words, err := tokenizer.Split(c.text)
+		switch {
+		case err != nil:
+			t.Errorf("cannot split %#v: %v", c.text, err)
+		case !reflect.DeepEqual(words, c.words):
+			t.Errorf("split %#v = %#v: %#v", c.text, words, c.words)
+		}
+	}
+
+	for _, text := range []string{"${HOME", "${}", "${HO-ME}"} {
+		if words, err := tokenizer.Split(text); err == nil {
+			// expect failure
+			t.Errorf("expect cannot split %#v: %#v", text, words)
+		}
+	}
+
+	if words, _ := SplitWords("$HOME"); !reflect.DeepEqual(words, []string{"$HOME"}) {
+		t.Errorf("expect not expand by default: %#v", words)
+	}
+}
+
+type Bot struct {
+	Channel string `short:"c" option:"required"`
+	Message *string
+}
+
+func TestParseLine(t *testing.T) {
+	bot := Bot{}
+	parser := MustNew(&bot)
+
+	if _, err := parser.ParseLine(`-c general "hello,  world"`); err != nil {
+		t.Fatalf("cannot parse the line: %v", err)
+	}
+
+	switch {
+	case bot.Channel != "general":
+		t.Errorf("expect --channel: %v", bot.Channel)
+	case bot.Message == nil || *bot.Message != "hello,  world":
+		t.Errorf("expect MESSAGE: %v", bot.Message)
+	}
+
+	if _, err := parser.ParseLine(`-c general 'hello`); err == nil || !strings.Contains(err.Error(), "unbalanced quote ' at line 1, column 12") {
+		t.Errorf("expect unbalanced quote: %v", err)
+	}
+
+	if _, err := MustNew(&Bot{}).ParseLine(`hello`); err == nil || err.Error() != "--channel is required" {
+		t.Errorf("expect --channel is required: %v", err)
+	}
+}
@@ -20,11 +20,24 @@ func _() {
 	_ = x[IFACE-9]
 	_ = x[IP-10]
 	_ = x[CIDR-11]
+	_ = x[ZONE-12]
+	_ = x[MONTH-13]
+	_ = x[WEEKDAY-14]
+	_ = x[PATH-15]
+	_ = x[ADDR-16]
+	_ = x[URL-17]
+	_ = x[MAC-18]
+	_ = x[PORT-19]
+	_ = x[IPSET-20]
+	_ = x[REGEXP-21]
+	_ = x[TMPL-22]
+	_ = x[JSON-23]
+	_ = x[BYTES-24]
 }
 
-const _TypeHint_name = "NONEINTUINTRATSTRFILEFMODETIMESPANIFACEIPCIDR"
+const _TypeHint_name = "NONEINTUINTRATSTRFILEFMODETIMESPANIFACEIPCIDRZONEMONTHWEEKDAYPATHADDRURLMACPORTIPSETREGEXPTMPLJSONBYTES"
 
-var _TypeHint_index = [...]uint8{0, 4, 7, 11, 14, 17, 21, 26, 30, 34, 39, 41, 45}
+var _TypeHint_index = [...]uint8{0, 4, 7, 11, 14, 17, 21, 26, 30, 34, 39, 41, 45, 49, 54, 61, 65, 69, 72, 75, 79, 84, 90, 94, 98, 103}
 
 func (i TypeHint) String() string {
 	if i < 0 || i >= TypeHint(len(_TypeHint_index)-1) {
@@ -0,0 +1,8 @@
+//go:build structopt_tzdata
+// +build structopt_tzdata
+
+package structopt
+
+// embed the time zone database as the fallback when the local zoneinfo is missing,
+// enabled by build with -tags structopt_tzdata
+import _ "time/tzdata"
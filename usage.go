@@ -0,0 +1,129 @@
+package structopt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the option shown as the row of the usage
+type usage_row interface {
+	// the short name, the long name and the words of the help
+	columns() (short, long string, help []string)
+}
+
+// the column widths of the usage, computed from all the rows
+type usage_layout struct {
+	// the width of the short name, like -n STR
+	short int
+	// the width of the flag column, the short and long names
+	flag int
+	// the maximal width of the row, never wrap if zero
+	width int
+}
+
+// compute the column widths from the options, and wrap the help in the width
+func new_usage_layout(options []Option, width int) (layout usage_layout) {
+	layout.width = width
+
+	for _, option := range options {
+		if short, _, _ := usage_columns(option); WidecharSize(short) > layout.short {
+			// the widest short name
+			layout.short = WidecharSize(short)
+		}
+	}
+
+	for _, option := range options {
+		short, long, _ := usage_columns(option)
+		if flag := layout.flag_of(option, short, long); WidecharSize(flag) > layout.flag {
+			// the widest flag
+			layout.flag = WidecharSize(flag)
+		}
+	}
+	return
+}
+
+// show the option as the aligned row, the continuation lines are indented to the help column
+func (layout usage_layout) row(option Option) (str string) {
+	short, long, help := usage_columns(option)
+	flag := layout.flag_of(option, short, long)
+	indent := USAGE_MARGIN + layout.flag + USAGE_GAP
+
+	help_width := 0
+	if layout.width > 0 {
+		// the remains width of the help column, overflow if too narrow
+		help_width = layout.width - indent
+		if help_width < USAGE_HELP_WIDTH {
+			help_width = USAGE_HELP_WIDTH
+		}
+	}
+
+	str = strings.Repeat(" ", USAGE_MARGIN) + pad_width(flag, layout.flag)
+	for idx, line := range wrap_words(help, help_width) {
+		switch idx {
+		case 0:
+			str = fmt.Sprintf("%v%v%v", str, strings.Repeat(" ", USAGE_GAP), line)
+		default:
+			str = fmt.Sprintf("%v\n%v%v", str, strings.Repeat(" ", indent), line)
+		}
+	}
+
+	str = strings.TrimRight(str, " ")
+	return
+}
+
+// the flag column, the short names of the options are right-aligned
+func (layout usage_layout) flag_of(option Option, short, long string) (flag string) {
+	switch typ := option.Type(); {
+	case (typ == Flip || typ == Flag) && layout.short > 0:
+		flag = fmt.Sprintf("%v%v %v", strings.Repeat(" ", layout.short-WidecharSize(short)), short, long)
+	default:
+		flag = long
+	}
+	return
+}
+
+// the columns of the option, the name only if not the usage row
+func usage_columns(option Option) (short, long string, help []string) {
+	switch row, ok := option.(usage_row); {
+	case ok:
+		short, long, help = row.columns()
+	default:
+		long = option.Name()
+	}
+	return
+}
+
+// pad the string with the spaces to the display width
+func pad_width(s string, width int) (padded string) {
+	padded = s
+	if size := WidecharSize(s); size < width {
+		padded = s + strings.Repeat(" ", width-size)
+	}
+	return
+}
+
+// the width of the usage: set by SetWidth, $COLUMNS, the terminal width or USAGE_WIDTH
+func (opt *StructOpt) usage_width() (width int) {
+	if width = opt.width; width > 0 {
+		// set by SetWidth
+		return
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+		return
+	}
+
+	if file, ok := opt.stderr.(*os.File); ok {
+		// the usage is shown in the stderr
+		width = terminal_width(file)
+	}
+
+	if width <= 0 {
+		// cannot detect the width
+		width = USAGE_WIDTH
+	}
+	return
+}
@@ -0,0 +1,62 @@
+package structopt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Wrapped struct {
+	Help
+
+	Mode    string   `short:"m" choice:"fast safe paranoid" default:"safe" help:"the mode of the synchronization between the replicas"`
+	Message string   `help:"顯示在每一個同步紀錄中的訊息"`
+	Files   []string `help:"the files to synchronize"`
+}
+
+func TestWrapWords(t *testing.T) {
+	cases := map[string][]string{
+		"":                            nil,
+		"a bb ccc":                    {"a bb", "ccc"},
+		"abcdefghij":                  {"abcde", "fghij"},
+		"x 顯示在每一個":                    {"x", "顯示", "在每", "一個"},
+		"(default: 2020-01-02T03:04)": {"(defa", "ult:", "2020-", "01-02", "T03:0", "4)"},
+	}
+
+	for text, lines := range cases {
+		if wrapped := wrap_words(strings.Fields(text), 5); !reflect.DeepEqual(wrapped, lines) {
+			t.Errorf("expect wrap %#v: %#v", text, wrapped)
+		}
+	}
+
+	if wrapped := wrap_words(strings.Fields("a bb ccc"), 0); !reflect.DeepEqual(wrapped, []string{"a bb ccc"}) {
+		t.Errorf("expect never wrap if zero: %#v", wrapped)
+	}
+}
+
+func TestUsageWidth(t *testing.T) {
+	parser := MustNew(&Wrapped{})
+	parser.SetWidth(50)
+
+	usage := "usage: wrapped [OPTION]\n" +
+		"\n" +
+		"options:\n" +
+		"        -h --help         show this message\n" +
+		"    -m STR --mode STR     the mode of the\n" +
+		"                          synchronization between\n" +
+		"                          the replicas [fast\n" +
+		"                          paranoid safe]\n" +
+		"                          (default: safe)\n" +
+		"           --message STR  顯示在每一個同步紀錄中的\n" +
+		"                          訊息\n" +
+		"           --files STR    the files to synchronize\n"
+	if str := parser.Usage(); str != usage {
+		t.Errorf("expect usage:\n%v\ngot:\n%v", usage, str)
+	}
+
+	t.Setenv("COLUMNS", "120")
+	parser.SetWidth(0)
+	if str := parser.Usage(); !strings.Contains(str, "the mode of the synchronization between the replicas [fast paranoid safe] (default: safe)\n") {
+		t.Errorf("expect the width from $COLUMNS:\n%v", str)
+	}
+}
@@ -3,8 +3,13 @@ package structopt
 import (
 	"fmt"
 	"math/big"
+	"os"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"golang.org/x/text/width"
 )
@@ -17,6 +22,11 @@ func AtoI(s string) (val int64, err error) {
 		s = s[1:]
 	}
 
+	if s, err = strip_underscore(s); err != nil {
+		// invalid underscore
+		return
+	}
+
 	switch {
 	case RE_HEX.MatchString(s):
 		if s = RE_HEX.FindStringSubmatch(s)[2]; minus {
@@ -48,6 +58,11 @@ func AtoI(s string) (val int64, err error) {
 
 // the strconv.Atoi wrapper for process the hexadecimal or other format
 func AtoU(s string) (val uint64, err error) {
+	if s, err = strip_underscore(s); err != nil {
+		// invalid underscore
+		return
+	}
+
 	switch {
 	case RE_HEX.MatchString(s):
 		val, err = strconv.ParseUint(RE_HEX.FindStringSubmatch(s)[2], 16, 64)
@@ -64,41 +79,390 @@ func AtoU(s string) (val uint64, err error) {
 	return
 }
 
+// the AtoI wrapper which also accept the unit suffix, like 64K or 1.5GiB
+func AtoIUnit(s, unit string) (val int64, err error) {
+	var num *big.Int
+
+	if unit == "" {
+		// no unit, same as AtoI
+		val, err = AtoI(s)
+		return
+	}
+
+	minus := false
+	if len(s) > 0 && s[0] == '-' {
+		minus = true
+		s = s[1:]
+	}
+
+	if num, err = parse_unit(s, unit); err != nil {
+		// invalid unit format
+		return
+	}
+
+	if minus {
+		num.Neg(num)
+	}
+
+	if !num.IsInt64() {
+		err = fmt.Errorf("value out of range: %v", num)
+		return
+	}
+	val = num.Int64()
+	return
+}
+
+// the AtoU wrapper which also accept the unit suffix, like 64K or 1.5GiB
+func AtoUUnit(s, unit string) (val uint64, err error) {
+	var num *big.Int
+
+	if unit == "" {
+		// no unit, same as AtoU
+		val, err = AtoU(s)
+		return
+	}
+
+	if num, err = parse_unit(s, unit); err != nil {
+		// invalid unit format
+		return
+	}
+
+	if !num.IsUint64() {
+		err = fmt.Errorf("value out of range: %v", num)
+		return
+	}
+	val = num.Uint64()
+	return
+}
+
+// format the sign integer with the unit suffix, the counterpart of AtoIUnit
+func ItoA(val int64, unit string) (s string) {
+	num := big.NewInt(val)
+
+	switch {
+	case unit == "":
+		s = num.String()
+	case val < 0:
+		s = "-" + format_unit(num.Neg(num), unit)
+	default:
+		s = format_unit(num, unit)
+	}
+	return
+}
+
+// format the unsign integer with the unit suffix, the counterpart of AtoUUnit
+func UtoA(val uint64, unit string) (s string) {
+	num := new(big.Int).SetUint64(val)
+
+	switch unit {
+	case "":
+		s = num.String()
+	default:
+		s = format_unit(num, unit)
+	}
+	return
+}
+
+// the strconv.ParseFloat wrapper which also accept the p/q, percentage and the sign integer
 func AtoF(s string) (val float64, err error) {
 	switch {
+	case strings.HasSuffix(s, "%"):
+		if val, err = AtoF(s[:len(s)-1]); err != nil {
+			// invalid percentage
+			return
+		}
+		val /= 100
 	case RE_FLOAT.MatchString(s):
 		val, err = strconv.ParseFloat(s, 64)
+	case RE_RAT.MatchString(s):
+		var rat *big.Rat
+
+		if rat, err = AtoR(s); err != nil {
+			// invalid rational number
+			return
+		}
+		val, _ = rat.Float64()
+	default:
+		// check is the simple sign int
+		var sign_val int64
+
+		if sign_val, err = AtoI(s); err == nil {
+			val = float64(sign_val)
+			return
+		}
+
+		// the special value, like inf / nan / hexadecimal float
+		if val, err = strconv.ParseFloat(s, 64); err != nil {
+			err = fmt.Errorf("not the RAT: %v", s)
+			return
+		}
+	}
+
+	return
+}
+
+// the exact rational number, like 3/7, 1.5, 1e-3 or 15%
+func AtoR(s string) (val *big.Rat, err error) {
+	switch {
+	case strings.HasSuffix(s, "%"):
+		if val, err = AtoR(s[:len(s)-1]); err != nil {
+			// invalid percentage
+			return
+		}
+		val.Quo(val, big.NewRat(100, 1))
+	case RE_FLOAT.MatchString(s):
+		var ok bool
+
+		if val, ok = new(big.Rat).SetString(s); !ok {
+			err = fmt.Errorf("not the RAT: %v", s)
+			return
+		}
 	case RE_RAT.MatchString(s):
 		pattern := strings.Split(s, "/")
-		var num int64
-		var denom int64
+		var num *big.Int
+		var denom *big.Int
 
-		if num, err = AtoI(pattern[0]); err != nil {
+		if num, err = AtoBigI(pattern[0]); err != nil {
 			// invalid numerator
 			return
 		}
-		if denom, err = AtoI(pattern[1]); err != nil {
+		if denom, err = AtoBigI(pattern[1]); err != nil {
 			// invalid denominator
 			return
 		}
 
-		rat := big.NewRat(num, denom)
-		val, _ = rat.Float64()
+		val = new(big.Rat).SetFrac(num, denom)
 	default:
-		// check is the simple sign int
-		var sign_val int64
+		var num *big.Int
 
-		if sign_val, err = AtoI(s); err != nil {
+		if num, err = AtoBigI(s); err != nil {
 			err = fmt.Errorf("not the RAT: %v", s)
 			return
 		}
+		val = new(big.Rat).SetInt(num)
+	}
+
+	return
+}
+
+// the arbitrary-precision float, accept the strconv.ParseFloat syntax, p/q and percentage
+func AtoBigF(s string) (val *big.Float, err error) {
+	switch {
+	case strings.HasSuffix(s, "%"):
+		if val, err = AtoBigF(s[:len(s)-1]); err != nil {
+			// invalid percentage
+			return
+		}
+		val.Quo(val, big.NewFloat(100))
+	case RE_RAT.MatchString(s):
+		var rat *big.Rat
+
+		if rat, err = AtoR(s); err != nil {
+			// invalid rational number
+			return
+		}
+		val = new(big.Float).SetRat(rat)
+	default:
+		var num *big.Int
+
+		if num, err = AtoBigI(s); err == nil {
+			val = new(big.Float).SetInt(num)
+			return
+		}
 
-		val = float64(sign_val)
+		if val, _, err = big.ParseFloat(s, 0, 0, big.ToNearestEven); err != nil {
+			err = fmt.Errorf("not the RAT: %v", s)
+			return
+		}
 	}
 
 	return
 }
 
+// the arbitrary-precision integer, accept the same format as AtoI
+func AtoBigI(s string) (val *big.Int, err error) {
+	var ok bool
+
+	minus := false
+	if len(s) > 0 && s[0] == '-' {
+		minus = true
+		s = s[1:]
+	}
+
+	if s, err = strip_underscore(s); err != nil {
+		// invalid underscore
+		return
+	}
+
+	switch {
+	case RE_HEX.MatchString(s):
+		val, ok = new(big.Int).SetString(RE_HEX.FindStringSubmatch(s)[2], 16)
+	case RE_OCT.MatchString(s):
+		val, ok = new(big.Int).SetString(RE_OCT.FindStringSubmatch(s)[2], 8)
+	case RE_BIN.MatchString(s):
+		val, ok = new(big.Int).SetString(RE_BIN.FindStringSubmatch(s)[2], 2)
+	case RE_INT.MatchString(s):
+		val, ok = new(big.Int).SetString(s, 10)
+	}
+
+	if !ok {
+		err = fmt.Errorf("not the sign INT: %v", s)
+		return
+	}
+
+	if minus {
+		val.Neg(val)
+	}
+	return
+}
+
+// the scale of the unit suffix
+type unit_scale struct {
+	suffix string
+	scale  int64
+}
+
+var (
+	binary_scales = []unit_scale{
+		{"Ei", 1 << 60}, {"Pi", 1 << 50}, {"Ti", 1 << 40}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10},
+	}
+	decimal_scales = []unit_scale{
+		{"E", 1e18}, {"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6}, {"k", 1e3},
+	}
+)
+
+// remove the Go-style underscore between digits, or return error
+func strip_underscore(s string) (out string, err error) {
+	out = s
+	if !strings.Contains(s, "_") {
+		// nothing to strip
+		return
+	}
+
+	if !RE_UNDERSCORE.MatchString(s) {
+		err = fmt.Errorf("invalid underscore: %v", s)
+		return
+	}
+
+	out = strings.ReplaceAll(s, "_", "")
+	return
+}
+
+// find the scale of the suffix in the unit
+func lookup_scale(suffix, unit string) (scale int64, err error) {
+	byte_suffix := false
+
+	if unit == UNIT_BYTES && len(suffix) > 0 && suffix[len(suffix)-1] == 'B' {
+		// the optional byte suffix
+		suffix = suffix[:len(suffix)-1]
+		byte_suffix = true
+	}
+
+	switch {
+	case suffix == "":
+		scale = 1
+		return
+	case len(suffix) == 2 && (suffix[1] == 'i' || suffix[1] == 'I'):
+		// the binary prefix, Ki / Mi / Gi ...
+		for _, s := range binary_scales {
+			if strings.EqualFold(s.suffix, suffix) {
+				scale = s.scale
+				return
+			}
+		}
+	case len(suffix) == 1:
+		scales := decimal_scales
+		if unit == UNIT_BYTES && !byte_suffix {
+			// the single-char suffix, K / M / G, is binary in bytes
+			scales = binary_scales
+		}
+
+		for _, s := range scales {
+			if strings.EqualFold(s.suffix[:1], suffix) {
+				scale = s.scale
+				return
+			}
+		}
+	}
+
+	err = fmt.Errorf("unknown %v suffix: %v", unit, suffix)
+	return
+}
+
+// parse the non-negative number with the unit suffix
+func parse_unit(s, unit string) (val *big.Int, err error) {
+	var scale int64
+
+	if unit != UNIT_BYTES && unit != UNIT_SI {
+		err = fmt.Errorf("unknown unit: %v", unit)
+		return
+	}
+
+	matched := RE_UNIT.FindStringSubmatch(s)
+	if matched == nil || RE_HEX.MatchString(s) || RE_BIN.MatchString(s) || RE_OCT.MatchString(s) {
+		// no unit suffix
+		var num uint64
+
+		if num, err = AtoU(s); err != nil {
+			return
+		}
+		val = new(big.Int).SetUint64(num)
+		return
+	}
+
+	if scale, err = lookup_scale(matched[2], unit); err != nil {
+		// invalid suffix
+		return
+	}
+
+	num, ok := new(big.Rat).SetString(strings.ReplaceAll(matched[1], "_", ""))
+	if !ok || strings.Contains(matched[1], "__") || strings.HasSuffix(matched[1], "_") {
+		err = fmt.Errorf("invalid number: %v", s)
+		return
+	}
+
+	num.Mul(num, new(big.Rat).SetInt64(scale))
+	if !num.IsInt() {
+		err = fmt.Errorf("not the integer: %v", s)
+		return
+	}
+
+	val = num.Num()
+	return
+}
+
+// format the non-negative number with the largest exact unit suffix
+func format_unit(val *big.Int, unit string) (s string) {
+	var scales [][]unit_scale
+
+	switch unit {
+	case UNIT_BYTES:
+		scales = [][]unit_scale{binary_scales, decimal_scales}
+	default:
+		scales = [][]unit_scale{decimal_scales, binary_scales}
+	}
+
+	if val.Sign() != 0 {
+		for _, candidates := range scales {
+			for _, scale := range candidates {
+				quo, rem := new(big.Int).QuoRem(val, big.NewInt(scale.scale), new(big.Int))
+				if rem.Sign() == 0 {
+					suffix := scale.suffix
+					if unit == UNIT_BYTES {
+						// the suffix in bytes always be KB / KiB
+						suffix = strings.ToUpper(suffix[:1]) + suffix[1:] + "B"
+					}
+					s = fmt.Sprintf("%v%v", quo, suffix)
+					return
+				}
+			}
+		}
+	}
+
+	s = val.String()
+	return
+}
+
 // [UTILITY] calculate the multi-char length
 func WidecharSize(s string) (size int) {
 	for _, r := range s {
@@ -111,3 +475,124 @@ func WidecharSize(s string) (size int) {
 	}
 	return
 }
+
+// wrap the words into the lines within the display width (never wrap if zero), the word wider
+// than the width is broken by the display width, like the CJK text without spaces.
+func wrap_words(words []string, width int) (lines []string) {
+	var line string
+
+	for _, word := range words {
+		for width > 0 && WidecharSize(word) > width {
+			if line != "" {
+				// flush the current line
+				lines = append(lines, line)
+				line = ""
+			}
+
+			var head string
+			for idx, r := range word {
+				if head != "" && WidecharSize(head+string(r)) > width {
+					// break the long word
+					word = word[idx:]
+					break
+				}
+				head += string(r)
+			}
+			lines = append(lines, head)
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case width > 0 && WidecharSize(line)+1+WidecharSize(word) > width:
+			lines = append(lines, line)
+			line = word
+		default:
+			line = fmt.Sprintf("%v %v", line, word)
+		}
+	}
+
+	if line != "" {
+		// the last line
+		lines = append(lines, line)
+	}
+	return
+}
+
+// the opaque type which is never deep-copied, the pointer is shared like *os.File
+func is_opaque_type(typ reflect.Type) (ok bool) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch reflect.New(typ).Elem().Interface().(type) {
+	case os.File, time.Location, regexp.Regexp, template.Template:
+		ok = true
+	}
+	return
+}
+
+// copy the value without sharing the pointer, slice and map, so the copy can be restored after
+// the value is modified in place, like the default value.
+func clone_value(value reflect.Value) (cloned reflect.Value) {
+	cloned = reflect.New(value.Type()).Elem()
+
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Struct:
+		if is_opaque_type(value.Type()) {
+			// the immutable or shared value, like *time.Location, keep as-is
+			cloned.Set(value)
+			return
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			ptr := reflect.New(value.Type().Elem())
+			ptr.Elem().Set(clone_value(value.Elem()))
+			cloned.Set(ptr)
+		}
+	case reflect.Slice:
+		if !value.IsNil() {
+			slice := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+			for idx := 0; idx < value.Len(); idx++ {
+				slice.Index(idx).Set(clone_value(value.Index(idx)))
+			}
+			cloned.Set(slice)
+		}
+	case reflect.Map:
+		if !value.IsNil() {
+			dict := reflect.MakeMapWithSize(value.Type(), value.Len())
+			for iter := value.MapRange(); iter.Next(); {
+				dict.SetMapIndex(iter.Key(), clone_value(iter.Value()))
+			}
+			cloned.Set(dict)
+		}
+	case reflect.Array:
+		for idx := 0; idx < value.Len(); idx++ {
+			cloned.Index(idx).Set(clone_value(value.Index(idx)))
+		}
+	case reflect.Struct:
+		switch num := value.Interface().(type) {
+		case big.Int:
+			// the big number modifies the internal slice in place
+			cloned.Set(reflect.ValueOf(new(big.Int).Set(&num)).Elem())
+		case big.Rat:
+			cloned.Set(reflect.ValueOf(new(big.Rat).Set(&num)).Elem())
+		case big.Float:
+			cloned.Set(reflect.ValueOf(new(big.Float).Copy(&num)).Elem())
+		default:
+			cloned.Set(value)
+			for idx := 0; idx < value.NumField(); idx++ {
+				if field := cloned.Field(idx); field.CanSet() {
+					// only the exported field can be copied
+					field.Set(clone_value(value.Field(idx)))
+				}
+			}
+		}
+	default:
+		cloned.Set(value)
+	}
+	return
+}
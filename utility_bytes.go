@@ -0,0 +1,102 @@
+package structopt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// decode the bytes by the encoding (hex, base64, base64url or raw), raw if encoding is empty
+func AtoBytes(s string, encoding string) (data []byte, err error) {
+	switch encoding {
+	case ENCODING_RAW, "":
+		data = []byte(s)
+	case ENCODING_HEX:
+		data, err = hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	case ENCODING_BASE64:
+		data, err = base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			// try the un-padded form
+			data, err = base64.RawStdEncoding.DecodeString(s)
+		}
+	case ENCODING_BASE64URL:
+		data, err = base64.URLEncoding.DecodeString(s)
+		if err != nil {
+			// try the un-padded form
+			data, err = base64.RawURLEncoding.DecodeString(s)
+		}
+	default:
+		err = fmt.Errorf("unknown encoding: %v", encoding)
+		return
+	}
+
+	if err != nil {
+		err = fmt.Errorf("invalid %v: %v", encoding, err)
+		return
+	}
+	return
+}
+
+// the length range from the exact length (32), the range (16-64) or the minimal length (16-)
+func AtoLength(s string) (min, max uint64, err error) {
+	first, last := s, s
+	if idx := strings.Index(s, "-"); idx >= 0 {
+		first, last = s[:idx], s[idx+1:]
+	}
+
+	if min, err = AtoU(first); err != nil {
+		err = fmt.Errorf("invalid length: %v", s)
+		return
+	}
+
+	switch last {
+	case "":
+		// no upper bound
+		max = math.MaxUint64
+	default:
+		if max, err = AtoU(last); err != nil || min > max {
+			err = fmt.Errorf("invalid length: %v", s)
+			return
+		}
+	}
+	return
+}
+
+// read the bytes from the file (@path), stdin (@-) or the literal value (@@ escape the leading @),
+// and then decode by the encoding.
+func read_bytes(s string, encoding string, stdin io.Reader) (data []byte, err error) {
+	var text []byte
+
+	switch {
+	case strings.HasPrefix(s, "@@"):
+		// the escaped literal value
+		data, err = AtoBytes(s[1:], encoding)
+		return
+	case s == "@-":
+		if text, err = ioutil.ReadAll(stdin); err != nil {
+			err = fmt.Errorf("cannot read stdin: %v", err)
+			return
+		}
+	case strings.HasPrefix(s, "@"):
+		if text, err = ioutil.ReadFile(s[1:]); err != nil {
+			err = fmt.Errorf("cannot read %#v: %v", s[1:], err)
+			return
+		}
+	default:
+		data, err = AtoBytes(s, encoding)
+		return
+	}
+
+	if encoding != ENCODING_RAW && encoding != "" {
+		// the text encoding may wrap lines
+		text = bytes.Join(bytes.Fields(text), nil)
+	}
+
+	data, err = AtoBytes(string(text), encoding)
+	return
+}
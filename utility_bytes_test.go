@@ -0,0 +1,128 @@
+package structopt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAtoBytes(t *testing.T) {
+	cases := []struct {
+		s        string
+		encoding string
+		ans      string
+	}{
+		{"hello", "", "hello"},
+		{"hello", ENCODING_RAW, "hello"},
+		{"68656c6c6f", ENCODING_HEX, "hello"},
+		{"0x68656C6C6F", ENCODING_HEX, "hello"},
+		{"aGVsbG8=", ENCODING_BASE64, "hello"},
+		{"aGVsbG8", ENCODING_BASE64, "hello"},
+		{"-_8=", ENCODING_BASE64URL, "\xfb\xff"},
+		{"-_8", ENCODING_BASE64URL, "\xfb\xff"},
+	}
+
+	for _, c := range cases {
+		data, err := AtoBytes(c.s, c.encoding)
+		switch {
+		case err != nil:
+			t.Errorf("cannot decode %v (%v): %v", c.s, c.encoding, err)
+		case string(data) != c.ans:
+			t.Errorf("AtoBytes(%v, %v) = %q: %q", c.s, c.encoding, data, c.ans)
+		}
+	}
+
+	for _, s := range []string{"6", "zz", "0xgg"} {
+		if data, err := AtoBytes(s, ENCODING_HEX); err == nil {
+			// expect failure
+			t.Errorf("expect cannot decode %v: %q", s, data)
+		}
+	}
+
+	if _, err := AtoBytes("a", "base32"); err == nil {
+		t.Errorf("expect unknown encoding")
+	}
+}
+
+func TestAtoLength(t *testing.T) {
+	cases := map[string][2]uint64{
+		"32":    {32, 32},
+		"16-64": {16, 64},
+	}
+
+	for s, ans := range cases {
+		min, max, err := AtoLength(s)
+		switch {
+		case err != nil:
+			t.Errorf("cannot parse %v: %v", s, err)
+		case min != ans[0] || max != ans[1]:
+			t.Errorf("AtoLength(%v) = %v-%v: %v", s, min, max, ans)
+		}
+	}
+
+	if _, max, err := AtoLength("16-"); err != nil || max < 1<<63 {
+		t.Errorf("expect the open length range: %v (%v)", max, err)
+	}
+
+	for _, s := range []string{"", "-16", "64-16", "x"} {
+		if _, _, err := AtoLength(s); err == nil {
+			// expect failure
+			t.Errorf("expect cannot parse %#v", s)
+		}
+	}
+}
+
+type Cipher struct {
+	Key     []byte   `encoding:"hex" len:"16-32" default:"000102030405060708090a0b0c0d0e0f"`
+	Nonce   [12]byte `encoding:"base64"`
+	Payload []byte
+}
+
+func TestBytesOption(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.hex")
+	if err := ioutil.WriteFile(path, []byte("00112233445566778899\naabbccddeeff\n"), 0600); err != nil {
+		t.Fatalf("cannot create %v: %v", path, err)
+	}
+
+	cipher := Cipher{}
+	parser := MustNew(&cipher)
+
+	if str := parser.named_options["key"].(*FlipFlag).String(); strings.Contains(str, "0001") || !strings.Contains(str, "(default: <16 bytes>)") {
+		t.Errorf("expect mask the default: %v", str)
+	}
+
+	parser.SetStdin(strings.NewReader("payload from stdin"))
+	args := []string{"--key", "@" + path, "--nonce", "AAECAwQFBgcICQoL", "--payload", "@-"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case !bytes.Equal(cipher.Key, []byte("\x00\x11\x22\x33\x44\x55\x66\x77\x88\x99\xaa\xbb\xcc\xdd\xee\xff")):
+		t.Errorf("expect --key from file: %x", cipher.Key)
+	case cipher.Nonce != [12]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}:
+		t.Errorf("expect --nonce: %x", cipher.Nonce)
+	case string(cipher.Payload) != "payload from stdin":
+		t.Errorf("expect --payload from stdin: %q", cipher.Payload)
+	}
+
+	if _, err := parser.Set("--payload", "@@literal"); err != nil || string(cipher.Payload) != "@literal" {
+		t.Errorf("expect --payload escape the leading @: %q (%v)", cipher.Payload, err)
+	}
+
+	cases := [][]string{
+		{"--key", "0011"},
+		{"--key", "zz"},
+		{"--nonce", "AAEC"},
+		{"--payload", "@" + filepath.Join(dir, "not-exists")},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package structopt
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// the os.OpenFile flag of the mode used in TAG_MODE
+var file_modes = map[string]int{
+	FILE_READ:      os.O_RDONLY,
+	FILE_WRITE:     os.O_WRONLY,
+	FILE_APPEND:    os.O_WRONLY | os.O_APPEND,
+	FILE_CREATE:    os.O_WRONLY | os.O_CREATE,
+	FILE_TRUNCATE:  os.O_WRONLY | os.O_TRUNC,
+	FILE_EXCLUSIVE: os.O_WRONLY | os.O_CREATE | os.O_EXCL,
+}
+
+// the file-permission, like 0644
+func AtoFileMode(s string) (mode os.FileMode, err error) {
+	var val uint64
+
+	val, err = AtoU(s)
+	if err != nil || val >= (1<<32) {
+		err = fmt.Errorf("invalid file-mode: %v (%v)", s, err)
+		return
+	}
+
+	mode = os.FileMode(val)
+	return
+}
+
+// the os.OpenFile flag from the modes (separate by space), like "read write" or "append create"
+func AtoFileFlag(s string) (flag int, err error) {
+	read := false
+	write := false
+
+	for _, mode := range strings.Fields(s) {
+		val, ok := file_modes[mode]
+		if !ok {
+			err = fmt.Errorf("unknown file mode: %v", mode)
+			return
+		}
+
+		switch mode {
+		case FILE_READ:
+			read = true
+		default:
+			write = true
+			flag |= val &^ os.O_WRONLY
+		}
+	}
+
+	switch {
+	case read && write:
+		flag |= os.O_RDWR
+	case write:
+		flag |= os.O_WRONLY
+	default:
+		flag |= os.O_RDONLY
+	}
+	return
+}
+
+// check the file can be opened by the flag, and return the stdin / stdout when path is -
+func check_file(path string, flag int) (std *os.File, err error) {
+	if path == "-" {
+		switch flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR) {
+		case os.O_RDONLY:
+			std = os.Stdin
+		default:
+			std = os.Stdout
+		}
+		return
+	}
+
+	info, e := os.Stat(path)
+	switch {
+	case e == nil && flag&os.O_EXCL != 0:
+		err = fmt.Errorf("file %#v already exists", path)
+	case e == nil && info.IsDir():
+		err = fmt.Errorf("%#v is not file", path)
+	case os.IsNotExist(e) && flag&os.O_CREATE == 0:
+		err = fmt.Errorf("file %#v does not exist", path)
+	case e != nil && !os.IsNotExist(e):
+		err = fmt.Errorf("cannot access file %#v: %v", path, e)
+	}
+	return
+}
+
+// expand the leading ~ as the home directory, and the environment variable (like $HOME) when env
+// is set, the unset variable is the error
+func ExpandPath(s string, env bool) (path string, err error) {
+	path = s
+	if env {
+		path = os.Expand(s, func(name string) (value string) {
+			var ok bool
+
+			if value, ok = os.LookupEnv(name); !ok && err == nil {
+				err = fmt.Errorf("cannot expand %v: $%v is not set", s, name)
+			}
+			return
+		})
+
+		if err != nil {
+			// the unset environment variable
+			return
+		}
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		var home string
+
+		if home, err = os.UserHomeDir(); err != nil {
+			err = fmt.Errorf("cannot expand %v: %v", s, err)
+			return
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return
+}
+
+// check the path matches the rules, like exists, dir, file or creatable
+func check_path(path string, rules map[string]struct{}) (err error) {
+	_, exists := rules[PATH_EXISTS]
+	_, dir := rules[PATH_DIR]
+	_, file := rules[PATH_FILE]
+	_, creatable := rules[PATH_CREATABLE]
+
+	info, e := os.Stat(path)
+	switch {
+	case e == nil && dir && !info.IsDir():
+		err = fmt.Errorf("%#v is not directory", path)
+	case e == nil && file && !info.Mode().IsRegular():
+		err = fmt.Errorf("%#v is not file", path)
+	case os.IsNotExist(e) && exists:
+		err = fmt.Errorf("%#v does not exist", path)
+	case os.IsNotExist(e) && creatable:
+		parent, e := os.Stat(filepath.Dir(path))
+		if e != nil || !parent.IsDir() {
+			err = fmt.Errorf("%#v cannot be created: parent directory does not exist", path)
+		}
+	case e != nil && !os.IsNotExist(e):
+		err = fmt.Errorf("cannot access %#v: %v", path, e)
+	}
+	return
+}
+
+// list the files / directories which has the prefix, used in completion
+func complete_path(prefix string, dir_only, env bool) (candidates []string) {
+	dir, base := filepath.Split(prefix)
+
+	path := dir
+	if path == "" {
+		// the current directory
+		path = "."
+	}
+
+	if expanded, err := ExpandPath(path, env); err == nil {
+		path = expanded
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		// cannot list the directory
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case !strings.HasPrefix(name, base):
+		case strings.HasPrefix(name, ".") && !strings.HasPrefix(base, "."):
+			// the hidden file
+		case entry.IsDir():
+			candidates = append(candidates, dir+name+string(filepath.Separator))
+		case !dir_only:
+			candidates = append(candidates, dir+name)
+		}
+	}
+
+	sort.Strings(candidates)
+	return
+}
+
+// the pattern has the glob meta char, like * ? or [
+func HasGlobMeta(pattern string) (ok bool) {
+	ok = strings.ContainsAny(pattern, "*?[")
+	return
+}
+
+// the filepath.Glob wrapper which also support the recursive ** pattern, like logs/**/*.gz
+func Glob(pattern string) (matches []string, err error) {
+	if !strings.Contains(pattern, "**") {
+		// the simple pattern
+		matches, err = filepath.Glob(pattern)
+		return
+	}
+
+	var re *regexp.Regexp
+	if re, err = glob_regexp(filepath.ToSlash(pattern)); err != nil {
+		// invalid pattern
+		return
+	}
+
+	// walk from the longest directory without the meta char
+	root := "."
+	parts := strings.Split(filepath.ToSlash(pattern), "/")
+	for idx := range parts {
+		if HasGlobMeta(parts[idx]) {
+			if idx > 0 {
+				root = filepath.FromSlash(strings.Join(parts[:idx], "/"))
+				if root == "" {
+					// the absolute path
+					root = string(filepath.Separator)
+				}
+			}
+			break
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// skip the inaccessible path
+			return nil
+		}
+
+		name := filepath.ToSlash(path)
+		if root == "." && !strings.HasPrefix(pattern, "./") {
+			// the relative path
+			name = strings.TrimPrefix(name, "./")
+		}
+
+		if path != root && re.MatchString(name) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+
+	sort.Strings(matches)
+	return
+}
+
+// convert the glob pattern to the regexp, the ** matches any directories
+func glob_regexp(pattern string) (re *regexp.Regexp, err error) {
+	var builder strings.Builder
+
+	builder.WriteString("^")
+	for idx := 0; idx < len(pattern); idx++ {
+		switch ch := pattern[idx]; {
+		case strings.HasPrefix(pattern[idx:], "**/"):
+			builder.WriteString("(?:.*/)?")
+			idx += 2
+		case strings.HasPrefix(pattern[idx:], "**"):
+			builder.WriteString(".*")
+			idx++
+		case ch == '*':
+			builder.WriteString("[^/]*")
+		case ch == '?':
+			builder.WriteString("[^/]")
+		case ch == '[':
+			end := strings.IndexByte(pattern[idx:], ']')
+			if end < 0 {
+				err = filepath.ErrBadPattern
+				return
+			}
+
+			class := pattern[idx+1 : idx+end]
+			if strings.HasPrefix(class, "!") {
+				// the negative class in shell
+				class = "^" + class[1:]
+			}
+			builder.WriteString("[" + class + "]")
+			idx += end
+		case ch == '\\' && idx+1 < len(pattern):
+			idx++
+			builder.WriteString(regexp.QuoteMeta(pattern[idx : idx+1]))
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	builder.WriteString("$")
+
+	re, err = regexp.Compile(builder.String())
+	return
+}
@@ -0,0 +1,72 @@
+package structopt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.gz", "b.log", "x/c.gz", "x/y/d.gz", "x/y/e.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("cannot create %v: %v", path, err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("cannot create %v: %v", path, err)
+		}
+	}
+
+	cases := map[string][]string{
+		"*.gz":       {"a.gz"},
+		"**/*.gz":    {"a.gz", "x/c.gz", "x/y/d.gz"},
+		"x/**/*.gz":  {"x/c.gz", "x/y/d.gz"},
+		"x/**":       {"x/c.gz", "x/y", "x/y/d.gz", "x/y/e.txt"},
+		"**/[!a]*":   {"b.log", "x", "x/c.gz", "x/y", "x/y/d.gz", "x/y/e.txt"},
+		"**/*.none":  nil,
+		"x/y/?.t?t":  {"x/y/e.txt"},
+		"x/**/d.?z":  {"x/y/d.gz"},
+		"**/y/*.txt": {"x/y/e.txt"},
+	}
+
+	for pattern, ans := range cases {
+		matches, err := Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatalf("cannot glob %v: %v", pattern, err)
+		}
+
+		var expect []string
+		for _, name := range ans {
+			expect = append(expect, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+
+		if !reflect.DeepEqual(matches, expect) {
+			t.Errorf("expect Glob(%v) = %v: %v", pattern, expect, matches)
+		}
+	}
+}
+
+func TestAtoFileFlag(t *testing.T) {
+	cases := map[string]int{
+		"":                      os.O_RDONLY,
+		"read":                  os.O_RDONLY,
+		"read write":            os.O_RDWR,
+		"append create":         os.O_WRONLY | os.O_APPEND | os.O_CREATE,
+		"write create truncate": os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+		"read exclusive":        os.O_RDWR | os.O_CREATE | os.O_EXCL,
+	}
+
+	for mode, ans := range cases {
+		if flag, err := AtoFileFlag(mode); err != nil || flag != ans {
+			t.Errorf("AtoFileFlag(%#v) = %v: %v (%v)", mode, flag, ans, err)
+		}
+	}
+
+	if flag, err := AtoFileFlag("read delete"); err == nil {
+		// expect failure
+		t.Errorf("expect unknown file mode: %v", flag)
+	}
+}
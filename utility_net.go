@@ -0,0 +1,328 @@
+package structopt
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// The hostname resolver used in the IP option with TAG_RESOLVE.
+type Resolver interface {
+	// Lookup the IP addresses of the host
+	LookupIP(host string) ([]net.IP, error)
+}
+
+// The static hosts-map resolver, which never perform the DNS query, usually used in test.
+type StaticResolver map[string][]net.IP
+
+// Lookup the IP addresses of the host, case-insensitive
+func (resolver StaticResolver) LookupIP(host string) (ips []net.IP, err error) {
+	for name, addrs := range resolver {
+		if strings.EqualFold(name, host) {
+			ips = addrs
+			return
+		}
+	}
+
+	err = fmt.Errorf("no such host: %v", host)
+	return
+}
+
+// The network interface provider used in the IFACE option.
+type InterfaceProvider interface {
+	// List all the network interfaces
+	Interfaces() ([]net.Interface, error)
+	// List the assigned addresses of the network interface
+	Addrs(iface net.Interface) ([]net.Addr, error)
+}
+
+// The static network interface with its assigned addresses, used in StaticInterfaces.
+type StaticInterface struct {
+	net.Interface
+
+	// the assigned addresses in CIDR notation, like 10.0.0.5/24
+	Prefixes []string
+}
+
+// The static network interface provider, which never touch the host NICs, usually used in test.
+type StaticInterfaces []StaticInterface
+
+// List all the network interfaces
+func (provider StaticInterfaces) Interfaces() (ifaces []net.Interface, err error) {
+	for _, iface := range provider {
+		ifaces = append(ifaces, iface.Interface)
+	}
+	return
+}
+
+// List the assigned addresses of the network interface, matched by the index
+func (provider StaticInterfaces) Addrs(iface net.Interface) (addrs []net.Addr, err error) {
+	for _, static := range provider {
+		if static.Index != iface.Index {
+			continue
+		}
+
+		for _, prefix := range static.Prefixes {
+			var ip net.IP
+			var inet *net.IPNet
+
+			if ip, inet, err = net.ParseCIDR(prefix); err != nil {
+				// invalid address
+				return
+			}
+			addrs = append(addrs, &net.IPNet{IP: ip, Mask: inet.Mask})
+		}
+		return
+	}
+
+	err = fmt.Errorf("no such network interface: %v", iface.Name)
+	return
+}
+
+// The network port, the option accepts the number or the service name, like 80 or http.
+type Port uint16
+
+// The inclusive port range, the option accepts the single port or range, like 8080 or 8000-8100.
+type PortRange struct {
+	First Port
+	Last  Port
+}
+
+// The number of the ports in the range
+func (ports PortRange) Len() (size int) {
+	size = int(ports.Last) - int(ports.First) + 1
+	return
+}
+
+// The port is in the range or not
+func (ports PortRange) Contains(port Port) (ok bool) {
+	ok = ports.First <= port && port <= ports.Last
+	return
+}
+
+// Show the port range, like 8000-8100 or 8080
+func (ports PortRange) String() (str string) {
+	str = fmt.Sprintf("%v-%v", ports.First, ports.Last)
+	if ports.First == ports.Last {
+		// the single port
+		str = fmt.Sprintf("%v", ports.First)
+	}
+	return
+}
+
+// the default resolver by net.LookupIP
+type net_resolver struct{}
+
+func (net_resolver) LookupIP(host string) (ips []net.IP, err error) {
+	ips, err = net.LookupIP(host)
+	return
+}
+
+// the default network interface provider by the host NICs
+type net_interfaces struct{}
+
+func (net_interfaces) Interfaces() (ifaces []net.Interface, err error) {
+	ifaces, err = net.Interfaces()
+	return
+}
+
+func (net_interfaces) Addrs(iface net.Interface) (addrs []net.Addr, err error) {
+	addrs, err = iface.Addrs()
+	return
+}
+
+// find the network interface by the name (eth0), index (#2), MAC or the assigned IP
+func AtoIface(s string, provider InterfaceProvider) (iface net.Interface, err error) {
+	var ifaces []net.Interface
+
+	if ifaces, err = provider.Interfaces(); err != nil {
+		err = fmt.Errorf("cannot list network interfaces: %v", err)
+		return
+	}
+
+	for _, candidate := range ifaces {
+		if candidate.Name == s {
+			// always prefer the exactly matched name
+			iface = candidate
+			return
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(s, "#"):
+		var index uint64
+
+		if index, err = AtoU(s[1:]); err != nil {
+			err = fmt.Errorf("invalid interface index: %v", s)
+			return
+		}
+
+		for _, candidate := range ifaces {
+			if uint64(candidate.Index) == index {
+				iface = candidate
+				return
+			}
+		}
+	default:
+		if mac, e := net.ParseMAC(s); e == nil {
+			for _, candidate := range ifaces {
+				if bytes.Equal(candidate.HardwareAddr, mac) {
+					iface = candidate
+					return
+				}
+			}
+			break
+		}
+
+		if ip := net.ParseIP(s); ip != nil {
+			for _, candidate := range ifaces {
+				var addrs []net.Addr
+
+				if addrs, err = provider.Addrs(candidate); err != nil {
+					// cannot list the addresses
+					log.Info("cannot list the addresses of %v: %v", candidate.Name, err)
+					continue
+				}
+
+				for _, addr := range addrs {
+					if inet, ok := addr.(*net.IPNet); ok && inet.IP.Equal(ip) {
+						iface, err = candidate, nil
+						return
+					}
+				}
+			}
+		}
+	}
+
+	err = fmt.Errorf("no such network interface: %v", s)
+	return
+}
+
+// the IP is in the family, ip4 / ip6, or any if family is empty
+func match_family(ip net.IP, family string) (ok bool) {
+	switch family {
+	case RESOLVE_IP4:
+		ok = ip.To4() != nil
+	case RESOLVE_IP6:
+		ok = ip.To4() == nil && ip.To16() != nil
+	default:
+		ok = true
+	}
+	return
+}
+
+// resolve the hostname by the resolver, and only return the IP in the family
+func resolve_ip(resolver Resolver, host, family string) (ips []net.IP, err error) {
+	var addrs []net.IP
+
+	log.Debug("resolve %v (family: %v)", host, family)
+	if addrs, err = resolver.LookupIP(host); err != nil {
+		err = fmt.Errorf("cannot resolve %v: %v", host, err)
+		return
+	}
+
+	for _, addr := range addrs {
+		if match_family(addr, family) {
+			// the matched address
+			ips = append(ips, addr)
+		}
+	}
+
+	if len(ips) == 0 {
+		err = fmt.Errorf("cannot resolve %v: no %v address", host, family)
+		return
+	}
+	return
+}
+
+// the port from the number or the service name of the network (tcp or udp), like 80 or http
+func AtoPort(s string, network string) (port Port, err error) {
+	var val uint64
+
+	if val, err = AtoU(s); err == nil {
+		if val > 65535 {
+			err = fmt.Errorf("port out of range: %v", s)
+			return
+		}
+
+		port = Port(val)
+		return
+	}
+
+	var num int
+	if num, err = net.LookupPort(network, s); err != nil {
+		err = fmt.Errorf("invalid port: %v", s)
+		return
+	}
+
+	port = Port(num)
+	return
+}
+
+// the port range from the single port or the range, like 8080, http-alt or 8000-8100
+func AtoPortRange(s string) (ports PortRange, err error) {
+	if ports.First, err = AtoPort(s, "tcp"); err == nil {
+		// the single port, may be the hyphenated service name
+		ports.Last = ports.First
+		return
+	}
+
+	idx := strings.Index(s, "-")
+	if idx <= 0 {
+		// neither the port nor the range
+		return
+	}
+
+	if ports.First, err = AtoPort(s[:idx], "tcp"); err == nil {
+		ports.Last, err = AtoPort(s[idx+1:], "tcp")
+	}
+
+	if err != nil || ports.First > ports.Last {
+		err = fmt.Errorf("invalid port range: %v", s)
+		return
+	}
+	return
+}
+
+// split the host:port, the host may be empty (like :8080) or resolved if resolve is set, and the
+// service name of the port is looked up by the network (tcp or udp)
+func AtoHostPort(s string, network string, resolver Resolver, resolve bool, family string) (ip net.IP, zone string, port Port, err error) {
+	var host, service string
+
+	if host, service, err = net.SplitHostPort(s); err != nil {
+		err = fmt.Errorf("invalid ADDR: %v", s)
+		return
+	}
+
+	if port, err = AtoPort(service, network); err != nil {
+		return
+	}
+
+	if idx := strings.LastIndex(host, "%"); idx >= 0 {
+		// the IPv6 zone
+		host, zone = host[:idx], host[idx+1:]
+	}
+
+	switch ip = net.ParseIP(host); {
+	case host == "":
+		// listen on all the address
+	case ip != nil:
+		if !match_family(ip, family) {
+			err = fmt.Errorf("invalid ADDR: %v is not %v", s, family)
+			return
+		}
+	case resolve:
+		var ips []net.IP
+
+		if ips, err = resolve_ip(resolver, host, family); err != nil {
+			err = fmt.Errorf("invalid ADDR: %v", err)
+			return
+		}
+		ip = ips[0]
+	default:
+		err = fmt.Errorf("invalid ADDR: %v (hostname is not resolved)", s)
+		return
+	}
+	return
+}
@@ -0,0 +1,214 @@
+package structopt
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Ping struct {
+	Target  net.IP   `short:"t" resolve:""`
+	Gateway net.IP   `resolve:"ip6"`
+	Peers   []net.IP `resolve:"ip4"`
+	Bind    net.IP
+}
+
+func TestResolveOption(t *testing.T) {
+	ping := Ping{}
+	parser := MustNew(&ping)
+	parser.SetResolver(StaticResolver{
+		"example.com": {net.ParseIP("2001:db8::1"), net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")},
+	})
+
+	args := []string{"-t", "EXAMPLE.com", "--gateway", "example.com", "--peers", "example.com", "--peers", "10.0.0.1"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case !ping.Target.Equal(net.ParseIP("2001:db8::1")):
+		t.Errorf("expect --target resolved as the first address: %v", ping.Target)
+	case !ping.Gateway.Equal(net.ParseIP("2001:db8::1")):
+		t.Errorf("expect --gateway resolved as IPv6: %v", ping.Gateway)
+	case len(ping.Peers) != 3 || !ping.Peers[1].Equal(net.ParseIP("192.0.2.2")) || !ping.Peers[2].Equal(net.ParseIP("10.0.0.1")):
+		t.Errorf("expect --peers keep all IPv4 addresses: %v", ping.Peers)
+	}
+
+	cases := [][]string{
+		{"--bind", "example.com"},
+		{"-t", "unknown.example.com"},
+		{"--peers", "2001:db8::2"},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
+
+type Route struct {
+	Addr    netip.Addr     `family:"ip6"`
+	Network netip.Prefix   `cidr:"network"`
+	Iface   net.IPNet      `cidr:"host"`
+	Masked  *net.IPNet     `option:"flag" family:"ip4"`
+	Listen  netip.AddrPort `default:"127.0.0.1:8080"`
+}
+
+func TestNetipOption(t *testing.T) {
+	route := Route{}
+	parser := MustNew(&route)
+
+	args := []string{"--addr", "fe80::1%eth0", "--network", "10.0.0.0/8", "--iface", "10.0.0.5/24", "--masked", "10.0.0.5/24"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case route.Addr != netip.MustParseAddr("fe80::1%eth0"):
+		t.Errorf("expect --addr fe80::1%%eth0: %v", route.Addr)
+	case route.Network != netip.MustParsePrefix("10.0.0.0/8"):
+		t.Errorf("expect --network 10.0.0.0/8: %v", route.Network)
+	case route.Iface.String() != "10.0.0.5/24":
+		t.Errorf("expect --iface keep the host address: %v", route.Iface.String())
+	case route.Masked.String() != "10.0.0.0/24":
+		t.Errorf("expect --masked 10.0.0.0/24: %v", route.Masked)
+	case route.Listen != netip.MustParseAddrPort("127.0.0.1:8080"):
+		t.Errorf("expect default --listen: %v", route.Listen)
+	}
+
+	cases := [][]string{
+		{"--addr", "10.0.0.1"},
+		{"--network", "10.0.0.5/8"},
+		{"--masked", "2001:db8::/32"},
+		{"--listen", "127.0.0.1"},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
+
+type Proxy struct {
+	Listen   *net.TCPAddr `option:"flag" default:":8080"`
+	DNS      net.UDPAddr  `resolve:"ip4"`
+	Upstream *url.URL     `option:"flag" scheme:"http https"`
+	MAC      net.HardwareAddr
+	Port     Port
+	Range    PortRange `default:"8000-8100"`
+}
+
+func TestNetworkOption(t *testing.T) {
+	proxy := Proxy{}
+	parser := MustNew(&proxy)
+	parser.SetResolver(StaticResolver{"dns.local": {net.ParseIP("192.0.2.53")}})
+
+	if proxy.Listen == nil || proxy.Listen.Port != 8080 || proxy.Listen.IP != nil {
+		t.Errorf("expect default --listen :8080: %v", proxy.Listen)
+	}
+
+	args := []string{
+		"--dns", "dns.local:domain", "--upstream", "https://example.com/api", "--mac", "00:00:5e:00:53:01",
+		"--port", "http", "--range", "9000",
+	}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case proxy.DNS.String() != "192.0.2.53:53":
+		t.Errorf("expect --dns 192.0.2.53:53: %v", proxy.DNS.String())
+	case proxy.Upstream.Host != "example.com" || proxy.Upstream.Path != "/api":
+		t.Errorf("expect --upstream https://example.com/api: %v", proxy.Upstream)
+	case proxy.MAC.String() != "00:00:5e:00:53:01":
+		t.Errorf("expect --mac 00:00:5e:00:53:01: %v", proxy.MAC)
+	case proxy.Port != 80:
+		t.Errorf("expect --port http as 80: %v", proxy.Port)
+	case proxy.Range != PortRange{9000, 9000} || proxy.Range.Len() != 1:
+		t.Errorf("expect --range 9000: %v", proxy.Range)
+	}
+
+	cases := [][]string{
+		{"--listen", "example.com:80"},
+		{"--listen", "127.0.0.1"},
+		{"--upstream", "ftp://example.com"},
+		{"--mac", "00:00:5e"},
+		{"--port", "65536"},
+		{"--port", "80-81"},
+		{"--range", "8100-8000"},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+
+	// the hyphenated service name is never split as the range
+	port, lookup := net.LookupPort("tcp", "http-alt")
+	_, err := parser.Set("--port", "http-alt", "--range", "http-alt")
+	switch {
+	case lookup == nil && (err != nil || int(proxy.Port) != port || proxy.Range != PortRange{Port(port), Port(port)}):
+		t.Errorf("expect --port and --range http-alt as %v: %v %v (%v)", port, proxy.Port, proxy.Range, err)
+	case lookup != nil && (err == nil || !strings.Contains(err.Error(), "http-alt")):
+		t.Errorf("expect the error names http-alt: %v", err)
+	}
+
+	if candidates := parser.Complete("--upstream", "h"); len(candidates) != 2 || candidates[1] != "https://" {
+		t.Errorf("expect complete the scheme: %v", candidates)
+	}
+}
+
+type Capture struct {
+	Device  net.Interface  `short:"i"`
+	Monitor *net.Interface `option:"flag"`
+	Bridges []net.Interface
+}
+
+func TestIfaceOption(t *testing.T) {
+	mac, _ := net.ParseMAC("02:00:00:00:00:02")
+	capture := Capture{}
+	parser := MustNew(&capture)
+	parser.SetInterfaces(StaticInterfaces{
+		{Interface: net.Interface{Index: 1, Name: "lo"}, Prefixes: []string{"127.0.0.1/8", "::1/128"}},
+		{Interface: net.Interface{Index: 2, Name: "eth0", HardwareAddr: mac}, Prefixes: []string{"10.0.0.5/24"}},
+		{Interface: net.Interface{Index: 3, Name: "eth1"}},
+	})
+
+	args := []string{"-i", "eth1", "--monitor", "#2", "--bridges", "02-00-00-00-00-02", "--bridges", "::1"}
+	if _, err := parser.Set(args...); err != nil {
+		t.Fatalf("cannot set %v: %v", args, err)
+	}
+
+	switch {
+	case capture.Device.Name != "eth1":
+		t.Errorf("expect --device matched by name: %v", capture.Device.Name)
+	case capture.Monitor == nil || capture.Monitor.Name != "eth0":
+		t.Errorf("expect --monitor matched by index: %v", capture.Monitor)
+	case len(capture.Bridges) != 2 || capture.Bridges[0].Name != "eth0" || capture.Bridges[1].Name != "lo":
+		t.Errorf("expect --bridges matched by MAC and IP: %v", capture.Bridges)
+	}
+
+	if candidates := parser.Complete("-i", "eth"); !reflect.DeepEqual(candidates, []string{"eth0", "eth1"}) {
+		t.Errorf("expect complete the interface names: %v", candidates)
+	}
+
+	cases := [][]string{
+		{"-i", "eth2"},
+		{"-i", "#4"},
+		{"-i", "#eth0"},
+		{"-i", "02:00:00:00:00:03"},
+		{"-i", "10.0.0.6"},
+	}
+	for _, args := range cases {
+		if _, err := parser.Set(args...); err == nil {
+			// expect failure
+			t.Errorf("expect cannot set %v", args)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package structopt
 
 import (
+	"math"
+	"math/big"
 	"testing"
 )
 
@@ -20,6 +22,35 @@ func TestAtoX(t *testing.T) {
 	t.Run("4294967295", testUint("4294967295", 4294967295))
 	t.Run("9223372036854775807", testUint("9223372036854775807", 9223372036854775807))
 	t.Run("18446744073709551615", testUint("18446744073709551615", 18446744073709551615))
+
+	t.Run("10_000", testInt("10_000", 10000))
+	t.Run("-0x_FF", testInt("-0x_FF", -255))
+	t.Run("0b1_0", testUint("0b1_0", 2))
+}
+
+func TestAtoXUnit(t *testing.T) {
+	t.Run("64K", testIntUnit("64K", UNIT_BYTES, 65536))
+	t.Run("64KB", testIntUnit("64KB", UNIT_BYTES, 64000))
+	t.Run("1.5GiB", testIntUnit("1.5GiB", UNIT_BYTES, 1610612736))
+	t.Run("-2Mi", testIntUnit("-2Mi", UNIT_BYTES, -2097152))
+	t.Run("512B", testIntUnit("512B", UNIT_BYTES, 512))
+	t.Run("0x10", testIntUnit("0x10", UNIT_BYTES, 16))
+	t.Run("2M", testIntUnit("2M", UNIT_SI, 2000000))
+	t.Run("10_000k", testIntUnit("10_000k", UNIT_SI, 10000000))
+
+	t.Run("1Ki", testUintUnit("1Ki", UNIT_SI, 1024))
+	t.Run("16E", testUintUnit("16E", UNIT_SI, 16000000000000000000))
+
+	t.Run("1.5B", testUnitFailure("1.5B", UNIT_BYTES))
+	t.Run("1KB", testUnitFailure("1KB", UNIT_SI))
+	t.Run("1X", testUnitFailure("1X", UNIT_BYTES))
+	t.Run("1__0K", testUnitFailure("1__0K", UNIT_BYTES))
+
+	t.Run("65536", testFormatUnit(65536, UNIT_BYTES, "64KiB"))
+	t.Run("64000", testFormatUnit(64000, UNIT_BYTES, "64KB"))
+	t.Run("-1024", testFormatUnit(-1024, UNIT_BYTES, "-1KiB"))
+	t.Run("1000", testFormatUnit(1000, UNIT_SI, "1k"))
+	t.Run("1023", testFormatUnit(1023, UNIT_SI, "1023"))
 }
 
 func testInt(s string, ans int64) func(*testing.T) {
@@ -46,6 +77,90 @@ func testUint(s string, ans uint64) func(*testing.T) {
 	}
 }
 
+func TestAtoF(t *testing.T) {
+	t.Run("1.5", testFloat("1.5", 1.5))
+	t.Run("-.5", testFloat("-.5", -0.5))
+	t.Run("1.", testFloat("1.", 1))
+	t.Run("1e-3", testFloat("1e-3", 0.001))
+	t.Run("2.5E6", testFloat("2.5E6", 2500000))
+	t.Run("15%", testFloat("15%", 0.15))
+	t.Run("3/4", testFloat("3/4", 0.75))
+	t.Run("0x10", testFloat("0x10", 16))
+	t.Run("inf", testFloat("inf", math.Inf(1)))
+
+	t.Run("3/7", testRat("3/7", big.NewRat(3, 7)))
+	t.Run("-1/-3", testRat("-1/-3", big.NewRat(1, 3)))
+	t.Run("0.1", testRat("0.1", big.NewRat(1, 10)))
+	t.Run("12.5%", testRat("12.5%", big.NewRat(1, 8)))
+	t.Run("017", testRat("017", big.NewRat(15, 1)))
+}
+
+func testFloat(s string, ans float64) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoF(s)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoF(%v): %v", s, err)
+		case ans != val:
+			t.Errorf("AtoF(%v) = %v: %v", s, val, ans)
+		}
+	}
+}
+
+func testRat(s string, ans *big.Rat) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoR(s)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoR(%v): %v", s, err)
+		case ans.Cmp(val) != 0:
+			t.Errorf("AtoR(%v) = %v: %v", s, val, ans)
+		}
+	}
+}
+
+func testIntUnit(s, unit string, ans int64) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoIUnit(s, unit)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoIUnit(%v, %v): %v", s, unit, err)
+		case ans != val:
+			t.Errorf("AtoIUnit(%v, %v) = %v: %v", s, unit, val, ans)
+		}
+	}
+}
+
+func testUintUnit(s, unit string, ans uint64) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoUUnit(s, unit)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoUUnit(%v, %v): %v", s, unit, err)
+		case ans != val:
+			t.Errorf("AtoUUnit(%v, %v) = %v: %v", s, unit, val, ans)
+		}
+	}
+}
+
+func testUnitFailure(s, unit string) func(*testing.T) {
+	return func(t *testing.T) {
+		if val, err := AtoIUnit(s, unit); err == nil {
+			// expect failure
+			t.Errorf("expect AtoIUnit(%v, %v) failure: %v", s, unit, val)
+		}
+	}
+}
+
+func testFormatUnit(val int64, unit, ans string) func(*testing.T) {
+	return func(t *testing.T) {
+		if s := ItoA(val, unit); s != ans {
+			// not match the expect format
+			t.Errorf("ItoA(%v, %v) = %v: %v", val, unit, s, ans)
+		}
+	}
+}
+
 func TestWidecharSize(t *testing.T) {
 	t.Run("test", testWidecharSize("test", 4))
 	t.Run("測試", testWidecharSize("測試", 4))
@@ -0,0 +1,374 @@
+package structopt
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pre-define the epoch layout, used in TAG_LAYOUT
+const (
+	LAYOUT_UNIX   = "unix"
+	LAYOUT_UNIXMS = "unixms"
+	LAYOUT_UNIXUS = "unixus"
+	LAYOUT_UNIXNS = "unixns"
+)
+
+// pre-define the extended time duration unit
+const (
+	DAY  = 24 * time.Hour
+	WEEK = 7 * DAY
+)
+
+// the extended unit of the time duration, which not supported in time.ParseDuration
+var span_units = map[string]time.Duration{
+	"d": DAY,
+	"w": WEEK,
+}
+
+// the default layout of the TIME
+var default_layouts = []string{"rfc3339"}
+
+// the named layout can be used in TAG_LAYOUT
+var named_layouts = map[string]string{
+	"ansic":       time.ANSIC,
+	"unixdate":    time.UnixDate,
+	"rubydate":    time.RubyDate,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"kitchen":     time.Kitchen,
+	"date":        "2006-01-02",
+	"datetime":    "2006-01-02 15:04:05",
+	"time":        "15:04:05",
+}
+
+// the scale of the epoch layout
+var epoch_layouts = map[string]time.Duration{
+	LAYOUT_UNIX:   time.Second,
+	LAYOUT_UNIXMS: time.Millisecond,
+	LAYOUT_UNIXUS: time.Microsecond,
+	LAYOUT_UNIXNS: time.Nanosecond,
+}
+
+// parse the timestamp by the layouts (Go layout, the named layout or the epoch),
+// or the relative time against now, like -2h, now or yesterday.
+//
+// The zone-less timestamp is interpreted in loc, and UTC if loc is nil.
+func AtoT(s string, layouts []string, loc *time.Location, now time.Time) (val time.Time, err error) {
+	if len(layouts) == 0 {
+		// use the default layout
+		layouts = default_layouts
+	}
+
+	if val, err = relative_time(s, loc, now); err == nil {
+		// the relative time
+		return
+	}
+
+	zone := loc
+	if zone == nil {
+		// same as time.Parse
+		zone = time.UTC
+	}
+
+	for _, layout := range layouts {
+		if scale, ok := epoch_layouts[layout]; ok {
+			var epoch int64
+
+			if epoch, err = AtoI(s); err == nil {
+				per := int64(time.Second / scale)
+				val = time.Unix(epoch/per, epoch%per*int64(scale)).In(zone)
+				return
+			}
+			continue
+		}
+
+		if named, ok := named_layouts[layout]; ok {
+			// the named layout
+			layout = named
+		}
+
+		if val, err = time.ParseInLocation(layout, s, zone); err == nil {
+			// found the matched layout
+			return
+		}
+	}
+
+	err = fmt.Errorf("expect %v", strings.Join(LayoutFormats(layouts), TAG_LAYOUT_SEP))
+	return
+}
+
+// format the timestamp by the first layout, the counterpart of AtoT
+func TtoA(val time.Time, layouts []string) (s string) {
+	if len(layouts) == 0 {
+		// use the default layout
+		layouts = default_layouts
+	}
+
+	layout := layouts[0]
+	switch scale, ok := epoch_layouts[layout]; {
+	case ok:
+		per := int64(time.Second / scale)
+		s = fmt.Sprintf("%v", val.Unix()*per+int64(val.Nanosecond())/int64(scale))
+	default:
+		if named, ok := named_layouts[layout]; ok {
+			// the named layout
+			layout = named
+		}
+		s = val.Format(layout)
+	}
+	return
+}
+
+// show the human-readable format of the layouts
+func LayoutFormats(layouts []string) (formats []string) {
+	for _, layout := range layouts {
+		if named, ok := named_layouts[layout]; ok {
+			// show the real layout
+			layout = named
+		}
+		formats = append(formats, layout)
+	}
+	return
+}
+
+// the relative time against now, like now, today, yesterday, +30m or -2h.
+func relative_time(s string, loc *time.Location, now time.Time) (val time.Time, err error) {
+	if loc != nil {
+		now = now.In(loc)
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(s) {
+	case "now":
+		val = now
+	case "today":
+		val = midnight
+	case "yesterday":
+		val = midnight.AddDate(0, 0, -1)
+	case "tomorrow":
+		val = midnight.AddDate(0, 0, 1)
+	default:
+		var span time.Duration
+
+		if len(s) == 0 || (s[0] != '-' && s[0] != '+') {
+			err = fmt.Errorf("not the relative time: %v", s)
+			return
+		}
+
+		if span, err = AtoD(s); err != nil {
+			// invalid relative time
+			return
+		}
+		val = now.Add(span)
+	}
+	return
+}
+
+// the time.ParseDuration wrapper which also accept the day (d) and week (w) unit,
+// like 7d or 1d12h, and the ISO-8601 duration, like P1DT2H.
+func AtoD(s string) (val time.Duration, err error) {
+	if val, err = time.ParseDuration(s); err == nil {
+		// the standard time duration
+		return
+	}
+
+	minus := false
+	span := s
+	if len(span) > 0 && (span[0] == '-' || span[0] == '+') {
+		minus = span[0] == '-'
+		span = span[1:]
+	}
+
+	switch {
+	case RE_ISO_SPAN.MatchString(span) && span != "P" && !strings.HasSuffix(span, "T"):
+		matched := RE_ISO_SPAN.FindStringSubmatch(span)
+		units := []time.Duration{WEEK, DAY, time.Hour, time.Minute, time.Second}
+
+		val = 0
+		for idx, unit := range units {
+			var part time.Duration
+
+			if matched[idx+1] == "" {
+				// not set
+				continue
+			}
+
+			if part, err = scale_span(matched[idx+1], unit); err != nil {
+				err = fmt.Errorf("invalid time duration: %v", s)
+				return
+			}
+			val += part
+		}
+	case span != "" && RE_SPAN.ReplaceAllString(span, "") == "":
+		val = 0
+		for _, matched := range RE_SPAN.FindAllStringSubmatch(span, -1) {
+			var part time.Duration
+
+			if unit, ok := span_units[matched[2]]; ok {
+				// the extended unit
+				part, err = scale_span(matched[1], unit)
+			} else {
+				part, err = time.ParseDuration(matched[0])
+			}
+
+			if err != nil {
+				err = fmt.Errorf("invalid time duration: %v", s)
+				return
+			}
+			val += part
+		}
+	default:
+		err = fmt.Errorf("invalid time duration: %v", s)
+		return
+	}
+
+	if minus {
+		val = -val
+	}
+	return
+}
+
+// format the time duration with the extended unit, the counterpart of AtoD
+func DtoA(val time.Duration) (s string) {
+	units := []struct {
+		suffix string
+		span   time.Duration
+	}{
+		{"w", WEEK}, {"d", DAY}, {"h", time.Hour}, {"m", time.Minute},
+	}
+
+	switch {
+	case val == 0:
+		s = "0s"
+		return
+	case val < 0:
+		s = "-"
+		val = -val
+	}
+
+	for _, unit := range units {
+		if count := val / unit.span; count > 0 {
+			s = fmt.Sprintf("%v%v%v", s, int64(count), unit.suffix)
+			val %= unit.span
+		}
+	}
+
+	if val > 0 {
+		// the remains seconds, like 30s, 1.5s or 500ms
+		s = fmt.Sprintf("%v%v", s, val)
+	}
+	return
+}
+
+// the exact number multiple by the time duration unit
+func scale_span(s string, unit time.Duration) (val time.Duration, err error) {
+	num, ok := new(big.Rat).SetString(s)
+	if !ok || strings.Count(s, ".") > 1 {
+		err = fmt.Errorf("invalid number: %v", s)
+		return
+	}
+
+	num.Mul(num, new(big.Rat).SetInt64(int64(unit)))
+	if !num.IsInt() || !num.Num().IsInt64() {
+		err = fmt.Errorf("time duration out of range: %v", s)
+		return
+	}
+
+	val = time.Duration(num.Num().Int64())
+	return
+}
+
+// load the time zone from the IANA name (like Asia/Taipei), UTC, Local or the offset
+// (like +08:00 or UTC-5), resolved via the local zoneinfo or the embedded tzdata.
+func AtoLocation(s string) (loc *time.Location, err error) {
+	switch {
+	case strings.EqualFold(s, "local"):
+		loc = time.Local
+	case strings.EqualFold(s, "utc"):
+		loc = time.UTC
+	case RE_ZONE_OFFSET.MatchString(s):
+		matched := RE_ZONE_OFFSET.FindStringSubmatch(s)
+		hour, _ := strconv.Atoi(matched[2])
+		minute := 0
+		if matched[3] != "" {
+			// the optional minute
+			minute, _ = strconv.Atoi(matched[3])
+		}
+
+		if hour > 14 || minute >= 60 {
+			err = fmt.Errorf("invalid offset: %v", s)
+			return
+		}
+
+		offset := hour*60*60 + minute*60
+
+		if matched[1] == "-" {
+			offset = -offset
+		}
+		loc = time.FixedZone(s, offset)
+	default:
+		loc, err = time.LoadLocation(s)
+	}
+	return
+}
+
+// the month from the name (March), abbreviation (mar) or number (3)
+func AtoMonth(s string) (month time.Month, err error) {
+	var val int
+
+	if val, err = lookup_calendar(s, 1, 12, func(n int) string { return time.Month(n).String() }); err != nil {
+		err = fmt.Errorf("not the month: %v", s)
+		return
+	}
+
+	month = time.Month(val)
+	return
+}
+
+// the weekday from the name (Monday), abbreviation (mon) or number (0 and 7 are Sunday)
+func AtoWeekday(s string) (weekday time.Weekday, err error) {
+	var val int
+
+	if s == "7" {
+		// the ISO-8601 Sunday
+		s = "0"
+	}
+
+	if val, err = lookup_calendar(s, 0, 6, func(n int) string { return time.Weekday(n).String() }); err != nil {
+		err = fmt.Errorf("not the weekday: %v", s)
+		return
+	}
+
+	weekday = time.Weekday(val)
+	return
+}
+
+// find the number in [low, high] by the number, or the name / abbreviation (at least 3 chars)
+func lookup_calendar(s string, low, high int, name func(int) string) (val int, err error) {
+	if val, err = strconv.Atoi(s); err == nil {
+		if val < low || val > high {
+			err = fmt.Errorf("out of range [%v, %v]: %v", low, high, val)
+		}
+		return
+	}
+
+	if len(s) >= 3 {
+		for n := low; n <= high; n++ {
+			if candidate := name(n); len(s) <= len(candidate) && strings.EqualFold(candidate[:len(s)], s) {
+				val, err = n, nil
+				return
+			}
+		}
+	}
+
+	err = fmt.Errorf("unknown name: %v", s)
+	return
+}
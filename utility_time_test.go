@@ -0,0 +1,154 @@
+package structopt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAtoT(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 30, 0, 0, time.UTC)
+	taipei := time.FixedZone("CST", 8*60*60)
+
+	t.Run("rfc3339", testTime("2020-01-02T03:04:05Z", nil, nil, now, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	t.Run("date", testTime("2026-10-01", []string{"date"}, nil, now, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)))
+	t.Run("date-tz", testTime("2026-10-01", []string{"date"}, taipei, now, time.Date(2026, 9, 30, 16, 0, 0, 0, time.UTC)))
+	t.Run("layout", testTime("10/01 08:00", []string{"date", "01/02 15:04"}, nil, now, time.Date(0, 10, 1, 8, 0, 0, 0, time.UTC)))
+	t.Run("unix", testTime("1697500000", []string{"date", "unix"}, nil, now, time.Unix(1697500000, 0)))
+	t.Run("unixms", testTime("1697500000123", []string{"unixms"}, nil, now, time.Unix(1697500000, 123000000)))
+	t.Run("-2h", testTime("-2h", nil, nil, now, now.Add(-2*time.Hour)))
+	t.Run("+30m", testTime("+30m", nil, nil, now, now.Add(30*time.Minute)))
+	t.Run("yesterday", testTime("yesterday", nil, nil, now, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)))
+	t.Run("today-tz", testTime("today", nil, taipei, now, time.Date(2026, 10, 19, 0, 0, 0, 0, taipei)))
+
+	if val, err := AtoT("2026-10-01", nil, nil, now); err == nil {
+		// expect failure
+		t.Errorf("expect 2026-10-01 not the RFC-3339: %v", val)
+	}
+}
+
+func testTime(s string, layouts []string, loc *time.Location, now, ans time.Time) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoT(s, layouts, loc, now)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoT(%v, %v): %v", s, layouts, err)
+		case !ans.Equal(val):
+			t.Errorf("AtoT(%v, %v) = %v: %v", s, layouts, val, ans)
+		}
+	}
+}
+
+func TestTtoA(t *testing.T) {
+	val := time.Date(2023, 10, 16, 23, 46, 40, 0, time.UTC)
+
+	if s := TtoA(val, nil); s != "2023-10-16T23:46:40Z" {
+		t.Errorf("expect RFC-3339: %v", s)
+	}
+	if s := TtoA(val, []string{"date"}); s != "2023-10-16" {
+		t.Errorf("expect date: %v", s)
+	}
+	if s := TtoA(val, []string{"unix"}); s != "1697500000" {
+		t.Errorf("expect unix: %v", s)
+	}
+}
+
+func TestAtoD(t *testing.T) {
+	t.Run("1h30m", testSpan("1h30m", 90*time.Minute))
+	t.Run("7d", testSpan("7d", 7*DAY))
+	t.Run("2w", testSpan("2w", 2*WEEK))
+	t.Run("1d12h", testSpan("1d12h", 36*time.Hour))
+	t.Run("-1.5d", testSpan("-1.5d", -36*time.Hour))
+	t.Run("1w2d3h4m5.5s", testSpan("1w2d3h4m5.5s", WEEK+2*DAY+3*time.Hour+4*time.Minute+5500*time.Millisecond))
+	t.Run("P1DT2H", testSpan("P1DT2H", 26*time.Hour))
+	t.Run("PT0.5S", testSpan("PT0.5S", 500*time.Millisecond))
+	t.Run("P2W", testSpan("P2W", 2*WEEK))
+
+	for _, s := range []string{"", "d", "1x", "P", "PT", "P1H", "1d-2h"} {
+		if val, err := AtoD(s); err == nil {
+			// expect failure
+			t.Errorf("expect AtoD(%#v) failure: %v", s, val)
+		}
+	}
+}
+
+func testSpan(s string, ans time.Duration) func(*testing.T) {
+	return func(t *testing.T) {
+		val, err := AtoD(s)
+		switch {
+		case err != nil:
+			t.Fatalf("cannot run AtoD(%v): %v", s, err)
+		case ans != val:
+			t.Errorf("AtoD(%v) = %v: %v", s, val, ans)
+		}
+	}
+}
+
+func TestDtoA(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                            "0s",
+		90 * time.Minute:             "1h30m",
+		36 * time.Hour:               "1d12h",
+		-2 * WEEK:                    "-2w",
+		DAY + 1500*time.Millisecond:  "1d1.5s",
+		WEEK + 3*DAY + 2*time.Second: "1w3d2s",
+	}
+
+	for span, ans := range cases {
+		s := DtoA(span)
+		if s != ans {
+			t.Errorf("DtoA(%v) = %v: %v", int64(span), s, ans)
+		}
+
+		if val, err := AtoD(s); err != nil || val != span {
+			t.Errorf("AtoD(%v) = %v: %v (%v)", s, val, span, err)
+		}
+	}
+}
+
+func TestAtoLocation(t *testing.T) {
+	for s, offset := range map[string]int{"UTC": 0, "+08:00": 8 * 60 * 60, "UTC-5": -5 * 60 * 60, "GMT+0530": 330 * 60} {
+		loc, err := AtoLocation(s)
+		if err != nil {
+			t.Fatalf("cannot run AtoLocation(%v): %v", s, err)
+		}
+
+		if _, val := time.Date(2026, 1, 1, 0, 0, 0, 0, loc).Zone(); val != offset {
+			t.Errorf("AtoLocation(%v) offset = %v: %v", s, val, offset)
+		}
+	}
+
+	if loc, err := AtoLocation("Mars/Olympus"); err == nil {
+		// expect failure
+		t.Errorf("expect unknown zone: %v", loc)
+	}
+}
+
+func TestAtoMonth(t *testing.T) {
+	for s, ans := range map[string]time.Month{"3": time.March, "march": time.March, "MAR": time.March, "sept": time.September} {
+		if month, err := AtoMonth(s); err != nil || month != ans {
+			t.Errorf("AtoMonth(%v) = %v: %v (%v)", s, month, ans, err)
+		}
+	}
+
+	for _, s := range []string{"0", "13", "ma", "marz"} {
+		if month, err := AtoMonth(s); err == nil {
+			// expect failure
+			t.Errorf("expect AtoMonth(%v) failure: %v", s, month)
+		}
+	}
+}
+
+func TestAtoWeekday(t *testing.T) {
+	for s, ans := range map[string]time.Weekday{"0": time.Sunday, "7": time.Sunday, "mon": time.Monday, "Thursday": time.Thursday} {
+		if weekday, err := AtoWeekday(s); err != nil || weekday != ans {
+			t.Errorf("AtoWeekday(%v) = %v: %v (%v)", s, weekday, ans, err)
+		}
+	}
+
+	for _, s := range []string{"8", "t", "mo"} {
+		if weekday, err := AtoWeekday(s); err == nil {
+			// expect failure
+			t.Errorf("expect AtoWeekday(%v) failure: %v", s, weekday)
+		}
+	}
+}